@@ -0,0 +1,355 @@
+package gosql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// aggregate holds the parts of a query for an aggregate of the rows in
+// a table that are shared by CountQuery and AggregateQuery.
+type aggregate struct {
+	db         *DB
+	queryRower QueryRowerContext
+	fn         string
+	column     string
+	table      string
+	joins      []string
+	joinArgs   []interface{}
+	wheres     []*where
+	whereArgs  []interface{}
+	havings    []*having
+	havingArgs []interface{}
+	groupBy    string
+	model      *model
+	softDelete softDeleteMode
+	distinct   bool
+	err        error
+}
+
+// setErr records the first error found while building the query, which
+// is returned when the query is run.
+func (a *aggregate) setErr(err error) {
+	if a.err == nil {
+		a.err = err
+	}
+}
+
+// setModel sets the model whose soft deleted rows are left out of the
+// query.
+func (a *aggregate) setModel(obj interface{}) {
+	m, err := a.db.getModelOf(reflect.TypeOf(obj))
+	a.setErr(err)
+	a.model = m
+}
+
+// check returns the error to report when the query is run, if any.
+func (a *aggregate) check() error {
+	if a.err != nil {
+		return a.err
+	}
+	if a.softDelete == onlyDeleted && a.model == nil {
+		return errNoSoftModel
+	}
+	return nil
+}
+
+func (a *aggregate) addWhere(conjunction string, condition interface{}, args []interface{}) {
+	c, args, err := toCondition(condition, args)
+	a.setErr(err)
+	a.wheres = append(a.wheres, &where{
+		conjunction: conjunction,
+		condition:   c,
+	})
+	a.whereArgs = append(a.whereArgs, args...)
+}
+
+func (a *aggregate) addHaving(conjunction string, condition interface{}, args []interface{}) {
+	c, args, err := toCondition(condition, args)
+	a.setErr(err)
+	a.havings = append(a.havings, &having{
+		conjunction: conjunction,
+		condition:   c,
+	})
+	a.havingArgs = append(a.havingArgs, args...)
+}
+
+func (a *aggregate) addJoin(kind string, join string, args []interface{}) {
+	join, args, err := bindNamed(join, args)
+	a.setErr(err)
+	a.joins = append(a.joins, fmt.Sprintf(" %s %s", kind, join))
+	a.joinArgs = append(a.joinArgs, args...)
+}
+
+// args returns the arguments of the query in the order of their
+// placeholders.
+func (a *aggregate) args() []interface{} {
+	args := make([]interface{}, 0, len(a.joinArgs)+len(a.whereArgs)+len(a.havingArgs))
+	args = append(args, a.joinArgs...)
+	args = append(args, a.whereArgs...)
+	return append(args, a.havingArgs...)
+}
+
+// sql returns the query with ? placeholders.
+func (a *aggregate) sql() string {
+	return a.aggregateSQL("")
+}
+
+// aggregateSQL returns the query selecting columns before the
+// aggregate, with ? placeholders.
+func (a *aggregate) aggregateSQL(columns string) string {
+	var q strings.Builder
+
+	q.WriteString("select ")
+	q.WriteString(columns)
+	q.WriteString(a.fn)
+	q.WriteString("(")
+	if a.distinct {
+		q.WriteString("distinct ")
+	}
+	q.WriteString(a.column)
+	q.WriteString(") from ")
+	q.WriteString(a.table)
+
+	for _, join := range a.joins {
+		q.WriteString(join)
+	}
+	writeWheres(&q, a.wheres, a.model.getSoftDeleteCondition(a.softDelete))
+	if a.groupBy != "" {
+		q.WriteString(" group by ")
+		q.WriteString(a.groupBy)
+	}
+	for i, having := range a.havings {
+		if i == 0 {
+			q.WriteString(" having ")
+		} else {
+			q.WriteString(having.conjunction)
+		}
+		q.WriteString(having.condition)
+	}
+	return q.String()
+}
+
+// queryGrouped runs the query selecting the group by expressions
+// before the aggregate. It returns the rows and the number of group by
+// columns.
+func (a *aggregate) queryGrouped(ctx context.Context) (*sql.Rows, int, error) {
+	if err := a.check(); err != nil {
+		return nil, 0, err
+	}
+	if a.groupBy == "" {
+		return nil, 0, fmt.Errorf("%s query must be grouped", a.fn)
+	}
+	querier, ok := a.queryRower.(QuerierContext)
+	if !ok {
+		return nil, 0, fmt.Errorf("%s query can not query rows", a.fn)
+	}
+	query, args := bind(a.db.dialect, a.aggregateSQL(a.groupBy+", "), a.args())
+	rows, err := querier.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		rows.Close()
+		return nil, 0, err
+	}
+	return rows, len(columns) - 1, nil
+}
+
+// scanGroup scans the group by columns of the current row, which are
+// returned with byte slices converted to strings, and the aggregate
+// into dest.
+func scanGroup(rows *sql.Rows, groupSize int, dest interface{}) ([]interface{}, error) {
+	group := make([]interface{}, groupSize)
+	dests := make([]interface{}, groupSize+1)
+	for i := range group {
+		dests[i] = &group[i]
+	}
+	dests[groupSize] = dest
+	if err := rows.Scan(dests...); err != nil {
+		return nil, err
+	}
+	for i, value := range group {
+		if b, ok := value.([]byte); ok {
+			group[i] = string(b)
+		}
+	}
+	return group, nil
+}
+
+// AggregateQuery is a query for the sum, average, minimum or maximum of
+// an expression over the rows in a table.
+type AggregateQuery struct {
+	aggregate
+}
+
+// GroupValue is the aggregate of one group of a grouped AggregateQuery.
+type GroupValue struct {
+	// Group holds the values of the group by expressions. Byte slices
+	// are converted to strings.
+	Group []interface{}
+
+	// Value is the aggregate as returned by the driver, or nil if it is
+	// null. Byte slices are converted to strings.
+	Value interface{}
+}
+
+// Where specifies which rows are aggregated. The condition is a string
+// or an expr.Expr.
+func (aq *AggregateQuery) Where(condition interface{}, args ...interface{}) *AggregateQuery {
+	aq.addWhere(" and ", condition, args)
+	return aq
+}
+
+// OrWhere specifies which rows are aggregated. The condition is a
+// string or an expr.Expr.
+func (aq *AggregateQuery) OrWhere(condition interface{}, args ...interface{}) *AggregateQuery {
+	aq.addWhere(" or ", condition, args)
+	return aq
+}
+
+// Having specifies a condition on the aggregated rows. The condition is
+// a string or an expr.Expr.
+func (aq *AggregateQuery) Having(condition interface{}, args ...interface{}) *AggregateQuery {
+	aq.addHaving(" and ", condition, args)
+	return aq
+}
+
+// OrHaving specifies a condition on the aggregated rows. The condition
+// is a string or an expr.Expr.
+func (aq *AggregateQuery) OrHaving(condition interface{}, args ...interface{}) *AggregateQuery {
+	aq.addHaving(" or ", condition, args)
+	return aq
+}
+
+// GroupBy specifies how to group the rows. A grouped query is run with
+// ExecGrouped.
+func (aq *AggregateQuery) GroupBy(bys ...string) *AggregateQuery {
+	aq.groupBy = strings.Join(bys, ", ")
+	return aq
+}
+
+// Join joins another table to this query. Named parameters in join are
+// bound from args.
+func (aq *AggregateQuery) Join(join string, args ...interface{}) *AggregateQuery {
+	aq.addJoin("join", join, args)
+	return aq
+}
+
+// LeftJoin joins another table to this query. Named parameters in join
+// are bound from args.
+func (aq *AggregateQuery) LeftJoin(join string, args ...interface{}) *AggregateQuery {
+	aq.addJoin("left join", join, args)
+	return aq
+}
+
+// Distinct aggregates only distinct values.
+func (aq *AggregateQuery) Distinct() *AggregateQuery {
+	aq.distinct = true
+	return aq
+}
+
+// Model sets the model of the table, which must be a pointer to a
+// model struct. If the model has a field tagged `soft:"delete"`, soft
+// deleted rows are left out of the aggregate. They are included if no
+// model is set.
+func (aq *AggregateQuery) Model(obj interface{}) *AggregateQuery {
+	aq.setModel(obj)
+	return aq
+}
+
+// WithDeleted makes the query aggregate soft deleted rows as well.
+func (aq *AggregateQuery) WithDeleted() *AggregateQuery {
+	aq.softDelete = withDeleted
+	return aq
+}
+
+// OnlyDeleted makes the query aggregate only soft deleted rows. The
+// model must be set by Model.
+func (aq *AggregateQuery) OnlyDeleted() *AggregateQuery {
+	aq.softDelete = onlyDeleted
+	return aq
+}
+
+// Exec executes the query and scans the result into dest, which must be
+// a pointer. If there are no rows to aggregate, the result is null: a
+// dest that implements sql.Scanner, such as *NullTime, is scanned as
+// null, and ErrNoValue is returned for any other dest.
+func (aq *AggregateQuery) Exec(dest interface{}) error {
+	return aq.ExecContext(context.Background(), dest)
+}
+
+// ExecContext is like Exec, but uses the given context.
+func (aq *AggregateQuery) ExecContext(ctx context.Context, dest interface{}) error {
+	if err := aq.check(); err != nil {
+		return err
+	}
+	if aq.groupBy != "" {
+		return fmt.Errorf("%s query is grouped and must be run with ExecGrouped", aq.fn)
+	}
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("dest must be a non nil pointer")
+	}
+	query, args := bind(aq.db.dialect, aq.sql(), aq.args())
+	row := aq.queryRower.QueryRowContext(ctx, query, args...)
+	if _, ok := dest.(sql.Scanner); ok {
+		return row.Scan(dest)
+	}
+	// scan into a pointer to the type of dest, which is left nil if the
+	// result is null
+	p := reflect.New(v.Type())
+	if err := row.Scan(p.Interface()); err != nil {
+		return err
+	}
+	if p.Elem().IsNil() {
+		return ErrNoValue
+	}
+	v.Elem().Set(p.Elem().Elem())
+	return nil
+}
+
+// ExecGrouped executes the query and returns the aggregate of each
+// group. The query must be grouped.
+func (aq *AggregateQuery) ExecGrouped() ([]*GroupValue, error) {
+	return aq.ExecGroupedContext(context.Background())
+}
+
+// ExecGroupedContext is like ExecGrouped, but uses the given context.
+func (aq *AggregateQuery) ExecGroupedContext(ctx context.Context) ([]*GroupValue, error) {
+	rows, groupSize, err := aq.queryGrouped(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var values []*GroupValue
+	for rows.Next() {
+		gv := new(GroupValue)
+		if gv.Group, err = scanGroup(rows, groupSize, &gv.Value); err != nil {
+			return nil, err
+		}
+		if b, ok := gv.Value.([]byte); ok {
+			gv.Value = string(b)
+		}
+		values = append(values, gv)
+	}
+	return values, rows.Err()
+}
+
+// String returns the string representation of AggregateQuery.
+func (aq *AggregateQuery) String() string {
+	return rebind(aq.db.dialect, aq.sql())
+}
+
+func (db *DB) newAggregateQuery(queryRower QueryRowerContext, fn string, table string, column string) *AggregateQuery {
+	aq := new(AggregateQuery)
+	aq.db = db
+	aq.queryRower = queryRower
+	aq.fn = fn
+	aq.table = table
+	aq.column = column
+	return aq
+}
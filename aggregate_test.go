@@ -0,0 +1,127 @@
+package gosql_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/twharmon/gosql"
+)
+
+func TestSum(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectQuery(`^select sum\(amount\) from payment join user on user\.id = payment\.user_id where user\.country = \? having count\(\*\) > \?$`).WithArgs("NZ", 1).WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(150))
+	var sum int64
+	check(t, db.Sum("payment", "amount").Join("user on user.id = payment.user_id").Where("user.country = ?", "NZ").Having("count(*) > ?", 1).Exec(&sum))
+	check(t, mock.ExpectationsWereMet())
+	equals(t, sum, int64(150))
+}
+
+func TestAvgDistinct(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithDialect(gosql.PostgreSQL))
+	check(t, err)
+	mock.ExpectQuery(`^select avg\(distinct amount\) from payment where amount > \$1$`).WithArgs(0).WillReturnRows(sqlmock.NewRows([]string{"avg"}).AddRow(2.5))
+	var avg float64
+	check(t, db.Avg("payment", "amount").Distinct().Where("amount > ?", 0).Exec(&avg))
+	check(t, mock.ExpectationsWereMet())
+	equals(t, avg, 2.5)
+}
+
+func TestMinNoValue(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectQuery(`^select min\(amount\) from payment$`).WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow(nil))
+	min := int64(5)
+	equals(t, db.Min("payment", "amount").Exec(&min), gosql.ErrNoValue)
+	check(t, mock.ExpectationsWereMet())
+	equals(t, min, int64(5))
+}
+
+func TestMaxNullTime(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectQuery(`^select max\(created_at\) from payment$`).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(nil))
+	max := gosql.NullTime{Valid: true}
+	check(t, db.Max("payment", "created_at").Exec(&max))
+	check(t, mock.ExpectationsWereMet())
+	equals(t, max.Valid, false)
+}
+
+func TestSumGrouped(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	rows := sqlmock.NewRows([]string{"user_id", "sum"}).AddRow(1, []byte("12.50")).AddRow(2, nil)
+	mock.ExpectQuery(`^select user_id, sum\(amount\) from payment group by user_id having count\(\*\) > \?$`).WithArgs(1).WillReturnRows(rows)
+	sums, err := db.Sum("payment", "amount").GroupBy("user_id").Having("count(*) > ?", 1).ExecGrouped()
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	equals(t, len(sums), 2)
+	equals(t, sums[0].Group[0], int64(1))
+	equals(t, sums[0].Value, "12.50")
+	if sums[1].Value != nil {
+		t.Fatalf("expected nil, got %v", sums[1].Value)
+	}
+}
+
+func TestAggregateGroupedExec(t *testing.T) {
+	db, _, err := getMockDB()
+	check(t, err)
+	var sum int64
+	if err := db.Sum("payment", "amount").GroupBy("user_id").Exec(&sum); err == nil {
+		t.Fatalf("expected err")
+	}
+	if _, err := db.Sum("payment", "amount").ExecGrouped(); err == nil {
+		t.Fatalf("expected err")
+	}
+}
+
+func TestAggregateTx(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^select max\(created_at\) from payment$`).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(now))
+	mock.ExpectCommit()
+	tx, err := db.Begin()
+	check(t, err)
+	var max gosql.NullTime
+	check(t, tx.Max("payment", "created_at").Exec(&max))
+	check(t, tx.Commit())
+	check(t, mock.ExpectationsWereMet())
+	equals(t, max.Time, now)
+}
+
+func TestAggregateInvalidDest(t *testing.T) {
+	db, _, err := getMockDB()
+	check(t, err)
+	var sum int64
+	if err := db.Sum("payment", "amount").Exec(sum); err == nil {
+		t.Fatal("expected error for dest that is not a pointer")
+	}
+}
+
+func TestAggregateSoftDeleted(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectQuery(`^select max\(id\) from soft_post where soft_post\.deleted_at is null$`).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(3))
+	var max int
+	check(t, db.Max("soft_post", "id").Model(&softPost{}).Exec(&max))
+	check(t, mock.ExpectationsWereMet())
+	equals(t, max, 3)
+}
+
+func TestAggregateSQLite(t *testing.T) {
+	db := getSQLiteDB(t, "create table payment (id integer not null primary key, amount integer); insert into payment (amount) values (1), (2), (6)")
+	var sum int64
+	check(t, db.Sum("payment", "amount").Exec(&sum))
+	equals(t, sum, int64(9))
+	var avg float64
+	check(t, db.Avg("payment", "amount").Exec(&avg))
+	equals(t, avg, 3.0)
+	var max sql.NullInt64
+	check(t, db.Max("payment", "amount").Where("amount > ?", 10).Exec(&max))
+	equals(t, max.Valid, false)
+	equals(t, db.Min("payment", "amount").Where("amount > ?", 10).Exec(&sum), gosql.ErrNoValue)
+}
@@ -0,0 +1,121 @@
+package gosql
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"regexp"
+)
+
+// bind expands the slice arguments of query and rewrites its ?
+// placeholders to the placeholders of the dialect.
+func bind(d Dialect, query string, args []interface{}) (string, []interface{}) {
+	query, args = expandArgs(query, args)
+	return rebind(d, query), args
+}
+
+// expandQuery binds the named parameters and expands the slice
+// arguments of a query written by hand, and rewrites its ? placeholders
+// to the placeholders of the dialect like those of built queries.
+func (db *DB) expandQuery(query string, args []interface{}) (string, []interface{}, error) {
+	query, args, err := bindNamed(query, args)
+	if err != nil {
+		return "", nil, err
+	}
+	query, args = bind(db.dialect, query, args)
+	return query, args, nil
+}
+
+// isExpandable reports whether arg is a slice that is expanded into one
+// argument per element. Byte slices and slices implementing
+// driver.Valuer are passed to the driver as they are.
+func isExpandable(arg interface{}) bool {
+	if arg == nil {
+		return false
+	}
+	if _, ok := arg.(driver.Valuer); ok {
+		return false
+	}
+	t := reflect.TypeOf(arg)
+	return t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8
+}
+
+// emptyIn matches the operand and in operator before the placeholder of
+// an empty slice, as in "id in (" or "lower(name) not in (".
+var emptyIn = regexp.MustCompile("(?i)(\\w*\\([^()]*\\)|[\\w.`\"\\[\\]]+)\\s+(not\\s+)?in\\s*\\(\\s*$")
+
+// closeParen matches the rest of an in list after the placeholder of an
+// empty slice.
+var closeParen = regexp.MustCompile(`^\s*\)`)
+
+// expandArgs replaces each ? placeholder in query whose argument is a
+// slice with one placeholder per element, and the slice with its
+// elements. A condition like "id in (?)" with an empty slice is
+// rewritten to 1=0, or 1=1 if it is negated. Other placeholders of
+// empty slices are replaced with null. Placeholders in quoted strings
+// and identifiers are left alone.
+func expandArgs(query string, args []interface{}) (string, []interface{}) {
+	expand := false
+	for _, arg := range args {
+		if isExpandable(arg) {
+			expand = true
+			break
+		}
+	}
+	if !expand {
+		return query, args
+	}
+	q := make([]byte, 0, len(query)+16)
+	expanded := make([]interface{}, 0, len(args))
+	var quote byte
+	n := 0
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '?':
+			if n >= len(args) {
+				break
+			}
+			arg := args[n]
+			n++
+			if !isExpandable(arg) {
+				expanded = append(expanded, arg)
+				break
+			}
+			v := reflect.ValueOf(arg)
+			if v.Len() == 0 {
+				loc := emptyIn.FindSubmatchIndex(q)
+				rest := closeParen.FindStringIndex(query[i+1:])
+				if loc == nil || rest == nil {
+					q = append(q, "null"...)
+					continue
+				}
+				negated := loc[4] >= 0
+				q = q[:loc[0]]
+				if negated {
+					q = append(q, "1=1"...)
+				} else {
+					q = append(q, "1=0"...)
+				}
+				i += rest[1]
+				continue
+			}
+			for j := 0; j < v.Len(); j++ {
+				if j > 0 {
+					q = append(q, ", "...)
+				}
+				q = append(q, '?')
+				expanded = append(expanded, v.Index(j).Interface())
+			}
+			continue
+		}
+		q = append(q, c)
+	}
+	expanded = append(expanded, args[n:]...)
+	return string(q), expanded
+}
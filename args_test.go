@@ -0,0 +1,117 @@
+package gosql_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/twharmon/gosql"
+)
+
+func TestExpandSelect(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithDialect(gosql.PostgreSQL))
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	mock.ExpectQuery(`^select \* from t where id in \(\$1, \$2, \$3\) and name = \$4$`).WithArgs(1, 2, 3, "foo").WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	var models []T
+	check(t, db.Select("*").Where("id in (?)", []int{1, 2, 3}).Where("name = ?", "foo").Get(&models))
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestExpandEmpty(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	mock.ExpectQuery(`^select \* from t where 1=0 and 1=1 and name = \?$`).WithArgs("foo").WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	var models []T
+	check(t, db.Select("*").Where("t.id in (?)", []int{}).Where("lower(name) not in ( ? )", []string(nil)).Where("name = ?", "foo").Get(&models))
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestExpandEmptyFallback(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectExec(`^update t set tags = coalesce\(null, tags\)$`).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.ManualUpdate("t").Set("tags = coalesce(?, tags)", []string{}).Exec()
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestExpandBytes(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectExec(`^delete from t where data = \? and id in \(\?, \?\)$`).WithArgs([]byte("foo"), 1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.ManualDelete("t").Where("data = ?", []byte("foo")).Where("id in (?)", []int64{1, 2}).Exec()
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestExpandCount(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectQuery(`^select count\(\*\) from t where name in \(\?, \?\)$`).WithArgs("a", "b").WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(2))
+	count, err := db.Count("t", "*").Where("name in (?)", []string{"a", "b"}).Exec()
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	equals(t, count, int64(2))
+}
+
+func TestExpandQuoted(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectExec(`^delete from t where name = '\?' and id in \(\?, \?\)$`).WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.Exec("delete from t where name = '?' and id in (?)", []int{1, 2})
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestExpandRawPostgreSQL(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithDialect(gosql.PostgreSQL))
+	check(t, err)
+	mock.ExpectQuery(`^select id from t where id in \(\$1, \$2\) and name = \$3$`).WithArgs(1, 2, "foo").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	rows, err := db.Query("select id from t where id in (?) and name = ?", []int{1, 2}, "foo")
+	check(t, err)
+	rows.Close()
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestRawPostgreSQL(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithDialect(gosql.PostgreSQL))
+	check(t, err)
+	mock.ExpectExec(`^update t set a = \$1 where id = \$2$`).WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.Exec("update t set a = ? where id = ?", 1, 2)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestExpandTx(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^select count\(\*\) from t where 1=0$`).WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(0))
+	mock.ExpectCommit()
+	tx, err := db.Begin()
+	check(t, err)
+	var count int
+	check(t, tx.QueryRow("select count(*) from t where id in (?)", []int{}).Scan(&count))
+	check(t, tx.Commit())
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestExpandSQLite(t *testing.T) {
+	db := getSQLiteDB(t, "create table t (id integer not null primary key, name text); insert into t (name) values ('a'), ('b'), ('c')")
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	var models []T
+	check(t, db.Select("*").Where("name in (?)", []string{"a", "c"}).Get(&models))
+	equals(t, len(models), 2)
+	check(t, db.Select("*").Where("name not in (?)", []string{}).Get(&models))
+	equals(t, len(models), 3)
+}
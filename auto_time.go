@@ -0,0 +1,49 @@
+package gosql
+
+import (
+	"reflect"
+	"time"
+)
+
+// isAutoTimeType reports whether t can be tagged `auto:"create_time"`
+// or `auto:"update_time"`.
+func isAutoTimeType(t reflect.Type) bool {
+	return t == timeType || t == nullTimeType || t == reflect.PtrTo(timeType)
+}
+
+// setTime sets f, which must be of a type accepted by isAutoTimeType,
+// to now.
+func setTime(f reflect.Value, now time.Time) {
+	switch f.Type() {
+	case timeType:
+		f.Set(reflect.ValueOf(now))
+	case nullTimeType:
+		f.Set(reflect.ValueOf(NullTime{Time: now, Valid: true}))
+	default:
+		f.Set(reflect.ValueOf(&now))
+	}
+}
+
+// setInsertTimes sets the field tagged `auto:"create_time"` of v to now
+// if it is zero, and the field tagged `auto:"update_time"` to now.
+func (m *model) setInsertTimes(v reflect.Value, now time.Time) {
+	if m.createTimeFieldIndex >= 0 && m.fieldValue(v, m.createTimeFieldIndex).IsZero() {
+		setTime(m.settableField(v, m.createTimeFieldIndex), now)
+	}
+	if m.updateTimeFieldIndex >= 0 {
+		setTime(m.settableField(v, m.updateTimeFieldIndex), now)
+	}
+}
+
+// setUpdateTime sets the field tagged `auto:"update_time"` of v to now
+// and returns fieldIndecies with the field added if it is missing.
+func (m *model) setUpdateTime(v reflect.Value, now time.Time, fieldIndecies []int) []int {
+	if m.updateTimeFieldIndex < 0 {
+		return fieldIndecies
+	}
+	setTime(m.settableField(v, m.updateTimeFieldIndex), now)
+	if isIntIn(m.updateTimeFieldIndex, fieldIndecies) {
+		return fieldIndecies
+	}
+	return append(fieldIndecies[:len(fieldIndecies):len(fieldIndecies)], m.updateTimeFieldIndex)
+}
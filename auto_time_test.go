@@ -0,0 +1,126 @@
+package gosql_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/twharmon/gosql"
+)
+
+type autoTimePost struct {
+	ID        int `idx:"primary"`
+	Body      string
+	CreatedAt time.Time      `auto:"create_time"`
+	UpdatedAt gosql.NullTime `auto:"update_time"`
+}
+
+var autoTimeNow = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func autoTimeClock() time.Time {
+	return autoTimeNow
+}
+
+func TestAutoTimeInsert(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithClock(autoTimeClock))
+	check(t, err)
+	post := autoTimePost{ID: 5, Body: "foo"}
+	mock.ExpectExec(`^insert into auto_time_post \(id, body, created_at, updated_at\) values \(\?, \?, \?, \?\)$`).WithArgs(post.ID, post.Body, autoTimeNow, autoTimeNow).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.Insert(&post)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	equals(t, post.CreatedAt, autoTimeNow)
+	equals(t, post.UpdatedAt, gosql.NullTime{Time: autoTimeNow, Valid: true})
+}
+
+func TestAutoTimeInsertKeepsCreateTime(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithClock(autoTimeClock))
+	check(t, err)
+	createdAt := autoTimeNow.Add(-time.Hour)
+	post := autoTimePost{ID: 5, Body: "foo", CreatedAt: createdAt}
+	mock.ExpectExec(`^insert into auto_time_post`).WithArgs(post.ID, post.Body, createdAt, autoTimeNow).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.Insert(&post)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestAutoTimeInsertMany(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithClock(autoTimeClock))
+	check(t, err)
+	posts := []autoTimePost{{ID: 1, Body: "foo"}, {ID: 2, Body: "bar"}}
+	mock.ExpectExec(`^insert into auto_time_post \(id, body, created_at, updated_at\) values \(\?, \?, \?, \?\), \(\?, \?, \?, \?\)$`).WithArgs(1, "foo", autoTimeNow, autoTimeNow, 2, "bar", autoTimeNow, autoTimeNow).WillReturnResult(sqlmock.NewResult(0, 2))
+	_, err = db.InsertMany(posts)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	equals(t, posts[1].CreatedAt, autoTimeNow)
+}
+
+func TestAutoTimeUpdate(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithClock(autoTimeClock))
+	check(t, err)
+	post := autoTimePost{ID: 5, Body: "foo"}
+	mock.ExpectExec(`^update auto_time_post set body = \?, updated_at = \? where id = \?$`).WithArgs(post.Body, autoTimeNow, post.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.Update(&post)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	equals(t, post.UpdatedAt.Time, autoTimeNow)
+}
+
+func TestAutoTimeUpdateColumnsTx(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithClock(autoTimeClock))
+	check(t, err)
+	post := autoTimePost{ID: 5, Body: "foo"}
+	mock.ExpectBegin()
+	mock.ExpectExec(`^update auto_time_post set body = \?, updated_at = \? where id = \?$`).WithArgs(post.Body, autoTimeNow, post.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	tx, err := db.Begin()
+	check(t, err)
+	_, err = tx.UpdateColumns(&post, "body")
+	check(t, err)
+	check(t, tx.Commit())
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestAutoTimeUpsert(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithClock(autoTimeClock))
+	check(t, err)
+	post := autoTimePost{ID: 5, Body: "foo"}
+	mock.ExpectExec(`^insert into auto_time_post \(id, body, created_at, updated_at\) values \(\?, \?, \?, \?\) on duplicate key update body = values\(body\), updated_at = values\(updated_at\)$`).WithArgs(post.ID, post.Body, autoTimeNow, autoTimeNow).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.Upsert(&post, nil)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestAutoTimeSoftDelete(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithClock(autoTimeClock))
+	check(t, err)
+	post := softPost{ID: 5}
+	mock.ExpectExec(`^update soft_post set deleted_at = \? where id = \?$`).WithArgs(gosql.NullTime{Time: autoTimeNow, Valid: true}, post.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.Delete(&post)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestAutoTimeWrongType(t *testing.T) {
+	db, _, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID        int    `idx:"primary"`
+		CreatedAt string `auto:"create_time"`
+	}
+	if err := db.Register(T{}); err == nil {
+		t.Fatal("expected error registering auto time field that is not a time")
+	}
+}
+
+func TestAutoTimeUnknownTag(t *testing.T) {
+	db, _, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID        int       `idx:"primary"`
+		CreatedAt time.Time `auto:"created"`
+	}
+	if err := db.Register(T{}); err == nil {
+		t.Fatal("expected error registering unknown auto tag")
+	}
+}
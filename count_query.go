@@ -1,8 +1,8 @@
 package gosql
 
 import (
+	"context"
 	"database/sql"
-	"fmt"
 	"strings"
 )
 
@@ -11,116 +11,142 @@ type QueryRower interface {
 	QueryRow(query string, args ...interface{}) *sql.Row
 }
 
+// QueryRowerContext .
+type QueryRowerContext interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
 // CountQuery is a query for counting rows in a table.
 type CountQuery struct {
-	db         *DB
-	queryRower QueryRower
-	count      string
-	table      string
-	joins      []string
-	wheres     []*where
-	whereArgs  []interface{}
-	havings    []*having
-	havingArgs []interface{}
-	groupBy    string
-}
-
-// Where specifies which rows will be returned.
-func (cq *CountQuery) Where(condition string, args ...interface{}) *CountQuery {
-	w := &where{
-		conjunction: " and ",
-		condition:   condition,
-	}
-	cq.wheres = append(cq.wheres, w)
-	cq.whereArgs = append(cq.whereArgs, args...)
+	aggregate
+}
+
+// Where specifies which rows will be returned. The condition is a
+// string or an expr.Expr.
+func (cq *CountQuery) Where(condition interface{}, args ...interface{}) *CountQuery {
+	cq.addWhere(" and ", condition, args)
 	return cq
 }
 
-// OrWhere specifies which rows will be returned.
-func (cq *CountQuery) OrWhere(condition string, args ...interface{}) *CountQuery {
-	w := &where{
-		conjunction: " or ",
-		condition:   condition,
-	}
-	cq.wheres = append(cq.wheres, w)
-	cq.whereArgs = append(cq.whereArgs, args...)
+// OrWhere specifies which rows will be returned. The condition is a
+// string or an expr.Expr.
+func (cq *CountQuery) OrWhere(condition interface{}, args ...interface{}) *CountQuery {
+	cq.addWhere(" or ", condition, args)
 	return cq
 }
 
-// Join joins another table to this query.
-func (cq *CountQuery) Join(join string) *CountQuery {
-	cq.joins = append(cq.joins, fmt.Sprintf(" join %s", join))
+// Join joins another table to this query. Named parameters in join are
+// bound from args.
+func (cq *CountQuery) Join(join string, args ...interface{}) *CountQuery {
+	cq.addJoin("join", join, args)
 	return cq
 }
 
-// LeftJoin joins another table to this query.
-func (cq *CountQuery) LeftJoin(join string) *CountQuery {
-	cq.joins = append(cq.joins, fmt.Sprintf(" left join %s", join))
+// LeftJoin joins another table to this query. Named parameters in join
+// are bound from args.
+func (cq *CountQuery) LeftJoin(join string, args ...interface{}) *CountQuery {
+	cq.addJoin("left join", join, args)
+	return cq
+}
+
+// GroupCount is the count of the rows of one group of a grouped
+// CountQuery.
+type GroupCount struct {
+	// Group holds the values of the group by expressions. Byte slices
+	// are converted to strings.
+	Group []interface{}
+	Count int64
+}
+
+// Distinct counts only distinct values.
+func (cq *CountQuery) Distinct() *CountQuery {
+	cq.distinct = true
 	return cq
 }
 
-// Exec executes the query.
+// Exec executes the query. If the query is grouped, the number of
+// groups is returned.
 func (cq *CountQuery) Exec() (int64, error) {
+	return cq.ExecContext(context.Background())
+}
+
+// ExecContext executes the query using the given context.
+func (cq *CountQuery) ExecContext(ctx context.Context) (int64, error) {
 	var count int64
-	row := cq.queryRower.QueryRow(cq.String(), cq.whereArgs...)
+	if err := cq.check(); err != nil {
+		return 0, err
+	}
+	query := cq.sql()
+	if cq.groupBy != "" {
+		query = "select count(*) from (" + query + ") " + cq.db.dialect.Quote("gosql_groups")
+	}
+	query, args := bind(cq.db.dialect, query, cq.args())
+	row := cq.queryRower.QueryRowContext(ctx, query, args...)
 	err := row.Scan(&count)
 	return count, err
 }
 
-// String returns the string representation of CountQuery.
-func (cq *CountQuery) String() string {
-	var q strings.Builder
-
-	q.WriteString("select count(")
-	q.WriteString(cq.count)
-	q.WriteString(") from ")
-	q.WriteString(cq.table)
+// ExecGrouped executes the query and returns the count of each group.
+// The query must be grouped.
+func (cq *CountQuery) ExecGrouped() ([]*GroupCount, error) {
+	return cq.ExecGroupedContext(context.Background())
+}
 
-	for _, join := range cq.joins {
-		q.WriteString(join)
+// ExecGroupedContext is like ExecGrouped, but uses the given context.
+func (cq *CountQuery) ExecGroupedContext(ctx context.Context) ([]*GroupCount, error) {
+	rows, groupSize, err := cq.queryGrouped(ctx)
+	if err != nil {
+		return nil, err
 	}
-	for i, where := range cq.wheres {
-		if i == 0 {
-			q.WriteString(" where ")
-		} else {
-			q.WriteString(where.conjunction)
+	defer rows.Close()
+	var counts []*GroupCount
+	for rows.Next() {
+		gc := new(GroupCount)
+		if gc.Group, err = scanGroup(rows, groupSize, &gc.Count); err != nil {
+			return nil, err
 		}
-		q.WriteString(where.condition)
+		counts = append(counts, gc)
 	}
-	if cq.groupBy != "" {
-		q.WriteString(" group by ")
-		q.WriteString(cq.groupBy)
-	}
-	for i, having := range cq.havings {
-		if i == 0 {
-			q.WriteString(" having ")
-		} else {
-			q.WriteString(having.conjunction)
-		}
-		q.WriteString(having.condition)
-	}
-	return q.String()
+	return counts, rows.Err()
 }
 
-// Having specifies which rows will be returned.
-func (cq *CountQuery) Having(condition string, args ...interface{}) *CountQuery {
-	h := &having{
-		conjunction: " and ",
-		condition:   condition,
-	}
-	cq.havings = append(cq.havings, h)
-	cq.havingArgs = append(cq.havingArgs, args...)
+// String returns the string representation of CountQuery.
+func (cq *CountQuery) String() string {
+	return rebind(cq.db.dialect, cq.sql())
+}
+
+// Model sets the model of the table, which must be a pointer to a
+// model struct. If the model has a field tagged `soft:"delete"`, soft
+// deleted rows are not counted. They are counted if no model is set.
+func (cq *CountQuery) Model(obj interface{}) *CountQuery {
+	cq.setModel(obj)
 	return cq
 }
 
-// OrHaving specifies which rows will be returned.
-func (cq *CountQuery) OrHaving(condition string, args ...interface{}) *CountQuery {
-	h := &having{
-		conjunction: " or ",
-		condition:   condition,
-	}
-	cq.havings = append(cq.havings, h)
-	cq.havingArgs = append(cq.havingArgs, args...)
+// WithDeleted makes the query count soft deleted rows as well.
+func (cq *CountQuery) WithDeleted() *CountQuery {
+	cq.softDelete = withDeleted
+	return cq
+}
+
+// OnlyDeleted makes the query count only soft deleted rows. The model
+// must be set by Model.
+func (cq *CountQuery) OnlyDeleted() *CountQuery {
+	cq.softDelete = onlyDeleted
+	return cq
+}
+
+// Having specifies which rows will be returned. The condition is a
+// string or an expr.Expr.
+func (cq *CountQuery) Having(condition interface{}, args ...interface{}) *CountQuery {
+	cq.addHaving(" and ", condition, args)
+	return cq
+}
+
+// OrHaving specifies which rows will be returned. The condition is a
+// string or an expr.Expr.
+func (cq *CountQuery) OrHaving(condition interface{}, args ...interface{}) *CountQuery {
+	cq.addHaving(" or ", condition, args)
 	return cq
 }
 
@@ -1,9 +1,11 @@
 package gosql_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/twharmon/gosql"
 )
 
 func TestCountStar(t *testing.T) {
@@ -51,3 +53,72 @@ func TestCountStarJoin(t *testing.T) {
 	check(t, mock.ExpectationsWereMet())
 	equals(t, control, test)
 }
+
+func TestCountExecContext(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	control := int64(15)
+	rows := sqlmock.NewRows([]string{"count(*)"})
+	rows.AddRow(control)
+	mock.ExpectQuery(`^select count\(\*\) from t$`).WillReturnRows(rows)
+	test, err := db.Count("t", "*").ExecContext(context.Background())
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	equals(t, control, test)
+}
+
+func TestCountHavingArgs(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectQuery(`^select count\(\*\) from \(select count\(\*\) from post where published = \? group by user_id having count\(\*\) > \?\) gosql_groups$`).WithArgs(true, 5).WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(2))
+	count, err := db.Count("post", "*").Where("published = ?", true).GroupBy("user_id").Having("count(*) > ?", 5).Exec()
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	equals(t, count, int64(2))
+}
+
+func TestCountDistinct(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectQuery(`^select count\(distinct user_id\) from post$`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	count, err := db.Count("post", "user_id").Distinct().Exec()
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	equals(t, count, int64(3))
+}
+
+func TestCountExecGrouped(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithDialect(gosql.PostgreSQL))
+	check(t, err)
+	mock.ExpectQuery(`^select user_id, status, count\(\*\) from post group by user_id, status having count\(\*\) > \$1$`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"user_id", "status", "count"}).AddRow(1, []byte("draft"), 2).AddRow(2, []byte("live"), 3))
+	counts, err := db.Count("post", "*").GroupBy("user_id", "status").Having("count(*) > ?", 1).ExecGrouped()
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	equals(t, len(counts), 2)
+	equals(t, counts[1].Group[0], int64(2))
+	equals(t, counts[1].Group[1], "live")
+	equals(t, counts[1].Count, int64(3))
+}
+
+func TestCountExecGroupedNotGrouped(t *testing.T) {
+	db, _, err := getMockDB()
+	check(t, err)
+	if _, err := db.Count("post", "*").ExecGrouped(); err == nil {
+		t.Fatal("expected error for grouped count without group by")
+	}
+}
+
+func TestCountExecGroupedSQLite(t *testing.T) {
+	db := getSQLiteDB(t, "create table post (id integer not null primary key, user_id integer, status text); insert into post (user_id, status) values (1, 'a'), (1, 'b'), (2, 'a'), (2, 'a'), (3, 'a')")
+	counts, err := db.Count("post", "*").GroupBy("status").Having("count(*) > ?", 1).ExecGrouped()
+	check(t, err)
+	equals(t, len(counts), 1)
+	equals(t, counts[0].Group[0], "a")
+	equals(t, counts[0].Count, int64(4))
+	count, err := db.Count("post", "user_id").Distinct().Where("status = ?", "a").Exec()
+	check(t, err)
+	equals(t, count, int64(3))
+	count, err = db.Count("post", "*").GroupBy("user_id").Having("count(*) > ?", 1).Exec()
+	check(t, err)
+	equals(t, count, int64(2))
+}
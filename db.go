@@ -1,43 +1,65 @@
 package gosql
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"reflect"
 	"regexp"
 	"strings"
+	"sync"
+	"time"
 )
 
-// DB is a wrapper around sql.DB.
+// DB is a wrapper around sql.DB. It is safe for concurrent use.
 type DB struct {
-	db     *sql.DB
-	models map[string]*model
+	db       *sql.DB
+	models   map[reflect.Type]*model
+	modelsMu sync.RWMutex
+	dialect  Dialect
+	clock    func() time.Time
+
+	// prepared is set if the statements generated for models are run as
+	// prepared statements, which are kept in stmts by their query.
+	prepared bool
+	stmts    sync.Map
 }
 
-func (db *DB) register(typ reflect.Type) error {
-	m := new(model)
-	m.typ = typ
-	m.name = m.typ.Name()
-	m.table = toSnakeCase(m.name)
-	m.primaryFieldIndecies = nil
-	for i := 0; i < m.typ.NumField(); i++ {
-		f := m.typ.Field(i)
-		if tag, ok := f.Tag.Lookup("idx"); ok && tag == "primary" {
-			m.primaryFieldIndecies = append(m.primaryFieldIndecies, i)
+// Register validates and registers models ahead of their first use, so
+// that misconfigured models are found at startup. Models are otherwise
+// registered the first time they are used. Register takes a pointer to
+// each model struct or the model struct itself.
+func (db *DB) Register(models ...interface{}) error {
+	db.modelsMu.Lock()
+	defer db.modelsMu.Unlock()
+	for _, obj := range models {
+		t := reflect.TypeOf(obj)
+		for t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t == nil || t.Kind() != reflect.Struct {
+			return fmt.Errorf("models must be structs or pointers to structs")
+		}
+		if db.models[t] != nil {
+			continue
 		}
-		if tag, ok := f.Tag.Lookup("col"); ok {
-			if tag == "-" {
-				continue
-			}
-			m.fields = append(m.fields, tag)
-		} else {
-			m.fields = append(m.fields, toSnakeCase(f.Name))
+		if err := db.register(t); err != nil {
+			return err
 		}
 	}
+	return nil
+}
+
+func (db *DB) register(typ reflect.Type) error {
+	m, err := newModel(typ, db.dialect)
+	if err != nil {
+		return err
+	}
 	if err := db.mustBeValid(m); err != nil {
 		return err
 	}
-	db.models[m.name] = m
+	db.models[typ] = m
 	return nil
 }
 
@@ -48,29 +70,94 @@ func (db *DB) getModelOf(t reflect.Type) (*model, error) {
 	if t.Kind() != reflect.Struct {
 		return nil, fmt.Errorf("obj must be a pointer to your model struct")
 	}
-	m := db.models[t.Name()]
-	if m == nil {
-		if err := db.register(t); err != nil {
-			return nil, err
+	db.modelsMu.RLock()
+	m := db.models[t]
+	db.modelsMu.RUnlock()
+	if m != nil {
+		return m, nil
+	}
+	db.modelsMu.Lock()
+	defer db.modelsMu.Unlock()
+	if m := db.models[t]; m != nil {
+		return m, nil
+	}
+	if err := db.register(t); err != nil {
+		return nil, err
+	}
+	return db.models[t], nil
+}
+
+// getModelOfSlice returns the model of the elements of slice, which must
+// be a slice, or a pointer to a slice, of structs or pointers to
+// structs. The elements are returned as struct values.
+func (db *DB) getModelOfSlice(slice interface{}) (*model, []reflect.Value, error) {
+	v := reflect.Indirect(reflect.ValueOf(slice))
+	if v.Kind() != reflect.Slice {
+		return nil, nil, fmt.Errorf("slice must be a slice of structs or a slice of pointers to structs")
+	}
+	m, err := db.getModelOf(v.Type().Elem())
+	if err != nil {
+		return nil, nil, err
+	}
+	values := make([]reflect.Value, v.Len())
+	for i := range values {
+		values[i] = reflect.Indirect(v.Index(i))
+		if !values[i].IsValid() {
+			return nil, nil, fmt.Errorf("slice must not contain nil pointers")
 		}
-		m = db.models[t.Name()]
 	}
-	return m, nil
+	return m, values, nil
 }
 
 func (db *DB) mustBeValid(m *model) error {
-	if db.models[m.name] != nil {
-		return fmt.Errorf("model %s found more than once", m.name)
-	}
 	if len(m.primaryFieldIndecies) == 0 {
 		return fmt.Errorf("model %s must have at least one field tagged `idx:\"primary\"`", m.name)
 	}
+	columns := make(map[string]bool)
+	for _, f := range m.fields {
+		if columns[f.column] {
+			return fmt.Errorf("model %s has more than one field for column %s", m.name, f.column)
+		}
+		columns[f.column] = true
+	}
 	return nil
 }
 
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+var timeType = reflect.TypeOf(time.Time{})
+
+// isSupportedType reports whether values of type t can be both written
+// to and scanned from the database.
+func isSupportedType(t reflect.Type) bool {
+	if reflect.PtrTo(t).Implements(scannerType) && (t.Implements(valuerType) || reflect.PtrTo(t).Implements(valuerType)) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8
+	case reflect.Ptr:
+		return isSupportedType(t.Elem())
+	case reflect.Interface:
+		return t.NumMethod() == 0
+	case reflect.Struct:
+		return t == timeType
+	}
+	return false
+}
+
 // Begin starts a transaction.
 func (db *DB) Begin() (*Tx, error) {
-	sqlTx, err := db.db.Begin()
+	return db.BeginTx(context.Background(), nil)
+}
+
+// BeginTx starts a transaction using the given context and options.
+func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	sqlTx, err := db.db.BeginTx(ctx, opts)
 	tx := Tx{
 		tx: sqlTx,
 		db: db,
@@ -78,53 +165,265 @@ func (db *DB) Begin() (*Tx, error) {
 	return &tx, err
 }
 
-// Insert insterts a row in the database.
+// Close closes the statements prepared by the DB, which are kept for its
+// life if it was made with WithPreparedStatements. The sql.DB passed to
+// New is left open for its owner to close.
+func (db *DB) Close() error {
+	var err error
+	db.stmts.Range(func(query, stmt interface{}) bool {
+		db.stmts.Delete(query)
+		if closeErr := stmt.(*sql.Stmt).Close(); err == nil {
+			err = closeErr
+		}
+		return true
+	})
+	return err
+}
+
+// Insert insterts a row in the database. If the primary field is
+// generated by the database, it is set on obj.
 func (db *DB) Insert(obj interface{}) (sql.Result, error) {
+	return db.InsertContext(context.Background(), obj)
+}
+
+// InsertContext insterts a row in the database using the given
+// context.
+func (db *DB) InsertContext(ctx context.Context, obj interface{}) (sql.Result, error) {
+	m, err := db.getModelOf(reflect.TypeOf(obj))
+	if err != nil {
+		return nil, err
+	}
+	v := reflect.ValueOf(obj).Elem()
+	return withHooks([]reflect.Value{v}, nil, beforeInsert, afterInsert, func() (sql.Result, error) {
+		return m.insert(ctx, db.executor(), v, db.clock())
+	})
+}
+
+// InsertMany inserts a row in the database for each element of slice,
+// which must be a slice of models or of pointers to models. The rows
+// are inserted with as few queries as the dialect allows, in a
+// transaction if more than one query is needed. That transaction is not
+// passed to the insert hooks. If the dialect supports returning columns
+// from an insert, primary fields generated by the database are set on
+// the elements.
+func (db *DB) InsertMany(slice interface{}) (sql.Result, error) {
+	return db.InsertManyContext(context.Background(), slice)
+}
+
+// InsertManyContext is like InsertMany, but uses the given context.
+func (db *DB) InsertManyContext(ctx context.Context, slice interface{}) (sql.Result, error) {
+	m, values, err := db.getModelOfSlice(slice)
+	if err != nil {
+		return nil, err
+	}
+	return withHooks(values, nil, beforeInsert, afterInsert, func() (sql.Result, error) {
+		fieldIndecies, err := m.getInsertManyFieldIndecies(values)
+		if err != nil {
+			return nil, err
+		}
+		if len(values) <= m.getInsertManyChunkSize(len(fieldIndecies)) {
+			return m.insertMany(ctx, db.db, values, db.clock())
+		}
+		tx, err := db.db.BeginTx(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		res, err := m.insertMany(ctx, tx, values, db.clock())
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		return res, tx.Commit()
+	})
+}
+
+// Upsert inserts a row in the database, or updates the row it conflicts
+// with as configured by opts. All columns other than the primary
+// columns are updated if opts is nil. The insert hooks of the model are
+// run whether it is inserted or updated.
+func (db *DB) Upsert(obj interface{}, opts *UpsertOptions) (sql.Result, error) {
+	return db.UpsertContext(context.Background(), obj, opts)
+}
+
+// UpsertContext is like Upsert, but uses the given context.
+func (db *DB) UpsertContext(ctx context.Context, obj interface{}, opts *UpsertOptions) (sql.Result, error) {
 	m, err := db.getModelOf(reflect.TypeOf(obj))
 	if err != nil {
 		return nil, err
 	}
 	v := reflect.ValueOf(obj).Elem()
-	return db.db.Exec(m.getInsertQuery(v), m.getArgs(v)...)
+	return withHooks([]reflect.Value{v}, nil, beforeInsert, afterInsert, func() (sql.Result, error) {
+		return m.upsert(ctx, db.db, v, opts, db.clock())
+	})
 }
 
-// Update updates a row in the database.
+// Update updates a row in the database. If the model embeds a
+// Snapshot, only the columns that changed since it was loaded are
+// updated.
 func (db *DB) Update(obj interface{}) (sql.Result, error) {
+	return db.UpdateContext(context.Background(), obj)
+}
+
+// UpdateContext updates a row in the database using the given context.
+func (db *DB) UpdateContext(ctx context.Context, obj interface{}) (sql.Result, error) {
 	m, err := db.getModelOf(reflect.TypeOf(obj))
 	if err != nil {
 		return nil, err
 	}
 	v := reflect.ValueOf(obj).Elem()
-	return db.db.Exec(m.getUpdateQuery(), m.getArgsPrimaryLast(v)...)
+	return withHooks([]reflect.Value{v}, nil, beforeUpdate, afterUpdate, func() (sql.Result, error) {
+		return m.update(ctx, db.executor(), v, m.getUpdateFieldIndecies(v), db.clock())
+	})
 }
 
-// Delete deletes a row from the database.
+// UpdateColumns updates the given columns of a row in the database.
+func (db *DB) UpdateColumns(obj interface{}, columns ...string) (sql.Result, error) {
+	return db.UpdateColumnsContext(context.Background(), obj, columns...)
+}
+
+// UpdateColumnsContext is like UpdateColumns, but uses the given
+// context.
+func (db *DB) UpdateColumnsContext(ctx context.Context, obj interface{}, columns ...string) (sql.Result, error) {
+	m, err := db.getModelOf(reflect.TypeOf(obj))
+	if err != nil {
+		return nil, err
+	}
+	fieldIndecies, err := m.getFieldIndeciesByColumns(columns)
+	if err != nil {
+		return nil, err
+	}
+	v := reflect.ValueOf(obj).Elem()
+	return withHooks([]reflect.Value{v}, nil, beforeUpdate, afterUpdate, func() (sql.Result, error) {
+		return m.update(ctx, db.executor(), v, fieldIndecies, db.clock())
+	})
+}
+
+// Delete deletes a row from the database. If the model has a field
+// tagged `soft:"delete"`, the row is soft deleted by setting the field
+// to the current time instead.
 func (db *DB) Delete(obj interface{}) (sql.Result, error) {
+	return db.DeleteContext(context.Background(), obj)
+}
+
+// DeleteContext deletes a row from the database using the given
+// context.
+func (db *DB) DeleteContext(ctx context.Context, obj interface{}) (sql.Result, error) {
+	m, err := db.getModelOf(reflect.TypeOf(obj))
+	if err != nil {
+		return nil, err
+	}
+	v := reflect.ValueOf(obj).Elem()
+	return withHooks([]reflect.Value{v}, nil, beforeDelete, afterDelete, func() (sql.Result, error) {
+		if m.softDeleteFieldIndex >= 0 {
+			return m.softDelete(ctx, db.executor(), v, db.clock())
+		}
+		return db.executor().ExecContext(ctx, m.getDeleteQuery(), m.getPrimaryArgs(v)...)
+	})
+}
+
+// HardDelete deletes a row from the database, even if the model has a
+// field tagged `soft:"delete"`.
+func (db *DB) HardDelete(obj interface{}) (sql.Result, error) {
+	return db.HardDeleteContext(context.Background(), obj)
+}
+
+// HardDeleteContext is like HardDelete, but uses the given context.
+func (db *DB) HardDeleteContext(ctx context.Context, obj interface{}) (sql.Result, error) {
 	m, err := db.getModelOf(reflect.TypeOf(obj))
 	if err != nil {
 		return nil, err
 	}
 	v := reflect.ValueOf(obj).Elem()
-	var inserts []interface{}
-	for _, i := range m.primaryFieldIndecies {
-		inserts = append(inserts, v.Field(i).Interface())
+	return withHooks([]reflect.Value{v}, nil, beforeDelete, afterDelete, func() (sql.Result, error) {
+		return db.executor().ExecContext(ctx, m.getDeleteQuery(), m.getPrimaryArgs(v)...)
+	})
+}
+
+// Restore restores a soft deleted row by setting the model's field
+// tagged `soft:"delete"` to null.
+func (db *DB) Restore(obj interface{}) (sql.Result, error) {
+	return db.RestoreContext(context.Background(), obj)
+}
+
+// RestoreContext is like Restore, but uses the given context.
+func (db *DB) RestoreContext(ctx context.Context, obj interface{}) (sql.Result, error) {
+	m, err := db.getModelOf(reflect.TypeOf(obj))
+	if err != nil {
+		return nil, err
 	}
-	return db.db.Exec(m.getDeleteQuery(), inserts...)
+	return m.restore(ctx, db.executor(), reflect.ValueOf(obj).Elem())
 }
 
 // Exec is a wrapper around sql.DB.Exec().
 func (db *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
-	return db.db.Exec(query, args...)
+	return db.ExecContext(context.Background(), query, args...)
+}
+
+// ExecContext is a wrapper around sql.DB.ExecContext().
+func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	query, args, err := db.expandQuery(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return db.db.ExecContext(ctx, query, args...)
 }
 
 // Query is a wrapper around sql.DB.Query().
 func (db *DB) Query(query string, args ...interface{}) (*sql.Rows, error) {
-	return db.db.Query(query, args...)
+	return db.QueryContext(context.Background(), query, args...)
+}
+
+// QueryContext is a wrapper around sql.DB.QueryContext().
+func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	query, args, err := db.expandQuery(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return db.db.QueryContext(ctx, query, args...)
 }
 
 // QueryRow is a wrapper around sql.DB.QueryRow().
 func (db *DB) QueryRow(query string, args ...interface{}) *sql.Row {
-	return db.db.QueryRow(query, args...)
+	return db.QueryRowContext(context.Background(), query, args...)
+}
+
+// QueryRowContext is a wrapper around sql.DB.QueryRowContext().
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	query, args, err := db.expandQuery(query, args)
+	if err != nil {
+		return errRow(ctx, db.db, err)
+	}
+	return db.db.QueryRowContext(ctx, query, args...)
+}
+
+// closedChan is a closed channel, which is the Done channel of an
+// errContext.
+var closedChan = make(chan struct{})
+
+func init() {
+	close(closedChan)
+}
+
+// errContext is a context that is done with err as its error.
+type errContext struct {
+	context.Context
+	err error
+}
+
+func (errContext) Done() <-chan struct{} {
+	return closedChan
+}
+
+func (c errContext) Err() error {
+	return c.err
+}
+
+// errRow returns a row whose Scan returns err. sql.Row can only be made
+// by a query, so a query is run with a context that is already done,
+// which makes sql.DB and sql.Tx return its error before getting a
+// connection. The query never reaches the driver.
+func errRow(ctx context.Context, q QueryRowerContext, err error) *sql.Row {
+	return q.QueryRowContext(errContext{ctx, err}, "")
 }
 
 // Select selects columns of a table.
@@ -150,11 +449,32 @@ func (db *DB) Count(table string, count string) *CountQuery {
 	cq := new(CountQuery)
 	cq.db = db
 	cq.queryRower = db.db
+	cq.fn = "count"
 	cq.table = table
-	cq.count = count
+	cq.column = count
 	return cq
 }
 
+// Sum starts a query for the sum of expr over the rows in a table.
+func (db *DB) Sum(table string, expr string) *AggregateQuery {
+	return db.newAggregateQuery(db.db, "sum", table, expr)
+}
+
+// Avg starts a query for the average of expr over the rows in a table.
+func (db *DB) Avg(table string, expr string) *AggregateQuery {
+	return db.newAggregateQuery(db.db, "avg", table, expr)
+}
+
+// Min starts a query for the minimum of expr over the rows in a table.
+func (db *DB) Min(table string, expr string) *AggregateQuery {
+	return db.newAggregateQuery(db.db, "min", table, expr)
+}
+
+// Max starts a query for the maximum of expr over the rows in a table.
+func (db *DB) Max(table string, expr string) *AggregateQuery {
+	return db.newAggregateQuery(db.db, "max", table, expr)
+}
+
 // ManualDelete starts a query for manually deleting rows in a table.
 func (db *DB) ManualDelete(table string) *DeleteQuery {
 	dq := new(DeleteQuery)
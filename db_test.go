@@ -1,10 +1,14 @@
 package gosql_test
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"os"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	_ "github.com/mattn/go-sqlite3"
@@ -213,6 +217,7 @@ func BenchmarkInsert(b *testing.B) {
 	user := User{Name: "Gopher"}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
+		user.ID = 0
 		_, err := db.Insert(&user)
 		check(b, err)
 	}
@@ -234,6 +239,54 @@ func BenchmarkUpdate(b *testing.B) {
 	}
 }
 
+func BenchmarkInsertPrepared(b *testing.B) {
+	db := getSQLiteDB(b, "create table user (id integer not null primary key, name text); delete from user", gosql.WithPreparedStatements())
+	type User struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	user := User{Name: "Gopher"}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		user.ID = 0
+		_, err := db.Insert(&user)
+		check(b, err)
+	}
+}
+
+func BenchmarkUpdatePrepared(b *testing.B) {
+	db := getSQLiteDB(b, "create table user (id integer not null primary key, name text); delete from user", gosql.WithPreparedStatements())
+	type User struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	user := User{Name: "Gopher"}
+	_, err := db.Insert(&user)
+	check(b, err)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := db.Update(&user)
+		check(b, err)
+	}
+}
+
+func BenchmarkInsertMany(b *testing.B) {
+	db := getSQLiteDB(b, "create table user (id integer not null primary key, name text); delete from user")
+	type User struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	users := make([]User, 100)
+	for i := range users {
+		users[i].Name = "Gopher"
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := db.InsertMany(users)
+		check(b, err)
+	}
+}
+
 func BenchmarkSelect(b *testing.B) {
 	db := getSQLiteDB(b, "create table user (id integer not null primary key, name text); delete from user")
 	type User struct {
@@ -258,6 +311,7 @@ func BenchmarkSelectMany(b *testing.B) {
 	}
 	user := User{Name: "Gopher"}
 	for i := 0; i < 100; i++ {
+		user.ID = 0
 		_, err := db.Insert(&user)
 		check(b, err)
 	}
@@ -276,6 +330,7 @@ func BenchmarkSelectManyPtrs(b *testing.B) {
 	}
 	user := User{Name: "Gopher"}
 	for i := 0; i < 100; i++ {
+		user.ID = 0
 		_, err := db.Insert(&user)
 		check(b, err)
 	}
@@ -285,3 +340,515 @@ func BenchmarkSelectManyPtrs(b *testing.B) {
 		check(b, db.Select("*").Limit(100).Get(&users))
 	}
 }
+
+// scanUser is a model with columns of several types for the scan
+// benchmarks.
+type scanUser struct {
+	ID        int64 `idx:"primary"`
+	Name      string
+	Email     string
+	Age       int64
+	Score     float64
+	Active    bool
+	CreatedAt time.Time
+	Bio       string
+}
+
+func getScanRowsDB(n int) *gosql.DB {
+	columns := []string{"id", "name", "email", "age", "score", "active", "created_at", "bio"}
+	rows := make([][]driver.Value, n)
+	for i := range rows {
+		rows[i] = []driver.Value{int64(i), "Gopher", "gopher@example.com", int64(10), 1.5, true, time.Now(), "Hello"}
+	}
+	return getRowsDB(columns, rows)
+}
+
+func BenchmarkScan(b *testing.B) {
+	db := getScanRowsDB(1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var user scanUser
+		check(b, db.Select("*").Get(&user))
+	}
+}
+
+func BenchmarkScanMany(b *testing.B) {
+	db := getScanRowsDB(100)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var users []scanUser
+		check(b, db.Select("*").Limit(100).Get(&users))
+	}
+}
+
+func BenchmarkScanManyPtrs(b *testing.B) {
+	db := getScanRowsDB(100)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var users []*scanUser
+		check(b, db.Select("*").Limit(100).Get(&users))
+	}
+}
+
+func TestInsertContext(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	insertModel := T{Name: "foo"}
+	mock.ExpectExec(`^insert into t \(name\) values \(\?\)$`).WithArgs(insertModel.Name).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.InsertContext(context.Background(), &insertModel)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateContextCanceled(t *testing.T) {
+	db, _, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := db.UpdateContext(ctx, &T{5, "foo"}); err != context.Canceled {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestDeleteContext(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID int `idx:"primary"`
+	}
+	deleteModel := T{5}
+	mock.ExpectExec(`^delete from t where id = \?$`).WithArgs(deleteModel.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.DeleteContext(context.Background(), &deleteModel)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestBeginTx(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	ctx := context.Background()
+	mock.ExpectBegin()
+	mock.ExpectExec(`^insert into t \(id, name\) values \(\?, \?\)$`).WithArgs(5, "foo").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	tx, err := db.BeginTx(ctx, nil)
+	check(t, err)
+	_, err = tx.InsertContext(ctx, &T{5, "foo"})
+	check(t, err)
+	check(t, tx.Commit())
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestRegister(t *testing.T) {
+	db, _, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID      int `idx:"primary"`
+		Name    gosql.NullString
+		Created time.Time
+		Data    []byte
+		Skipped map[string]string `col:"-"`
+	}
+	check(t, db.Register(&T{}, T{}))
+}
+
+func TestRegisterNoPrimary(t *testing.T) {
+	db, _, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID int
+	}
+	if err := db.Register(&T{}); err == nil {
+		t.Fatalf("expected err")
+	} else {
+		contains(t, err.Error(), "primary")
+	}
+}
+
+func TestRegisterDuplicateColumn(t *testing.T) {
+	db, _, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID       int `idx:"primary"`
+		Name     string
+		LastName string `col:"name"`
+	}
+	if err := db.Register(&T{}); err == nil {
+		t.Fatalf("expected err")
+	} else {
+		contains(t, err.Error(), "column name")
+	}
+}
+
+func TestRegisterUnsupportedType(t *testing.T) {
+	db, _, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Tags map[string]string
+	}
+	if err := db.Register(&T{}); err == nil {
+		t.Fatalf("expected err")
+	} else {
+		contains(t, err.Error(), "unsupported type")
+	}
+}
+
+func TestRegisterNotStruct(t *testing.T) {
+	db, _, err := getMockDB()
+	check(t, err)
+	if err := db.Register(5); err == nil {
+		t.Fatalf("expected err")
+	}
+}
+
+func TestConcurrentFirstUse(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.MatchExpectationsInOrder(false)
+	type T struct {
+		ID int `idx:"primary"`
+	}
+	const n = 10
+	for i := 0; i < n; i++ {
+		mock.ExpectExec(`^delete from t where id = \?$`).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			_, err := db.Delete(&T{id})
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		check(t, err)
+	}
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertSetsLastInsertID(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID   int64 `idx:"primary"`
+		Name string
+	}
+	model := T{Name: "foo"}
+	mock.ExpectExec(`^insert into t \(name\) values \(\?\)$`).WithArgs(model.Name).WillReturnResult(sqlmock.NewResult(9, 1))
+	_, err = db.Insert(&model)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	equals(t, int64(9), model.ID)
+}
+
+func TestInsertKeepsGivenPrimary(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID   uint `idx:"primary"`
+		Name string
+	}
+	model := T{ID: 5, Name: "foo"}
+	mock.ExpectExec(`^insert into t \(id, name\) values \(\?, \?\)$`).WithArgs(model.ID, model.Name).WillReturnResult(sqlmock.NewResult(9, 1))
+	_, err = db.Insert(&model)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	equals(t, uint(5), model.ID)
+}
+
+func TestInsertReturningString(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithDialect(gosql.PostgreSQL))
+	check(t, err)
+	type T struct {
+		ID   string `idx:"primary"`
+		Name string
+	}
+	model := T{Name: "foo"}
+	uuid := "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^insert into t \(name\) values \(\$1\) returning id$`).WithArgs(model.Name).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid))
+	tx, err := db.Begin()
+	check(t, err)
+	res, err := tx.Insert(&model)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	equals(t, uuid, model.ID)
+	if _, err := res.LastInsertId(); err == nil {
+		t.Fatalf("expected err for non integer id")
+	}
+}
+
+func ExampleDB_Insert_generatedID() {
+	os.Remove("/tmp/foo.db")
+	sqliteDB, _ := sql.Open("sqlite3", "/tmp/foo.db")
+	sqliteDB.Exec("create table user (id integer not null primary key, name text); delete from user")
+	db := gosql.New(sqliteDB, gosql.WithDialect(gosql.SQLite))
+	type User struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	db.Insert(&User{Name: "Gopher"})
+	user := User{Name: "Gofer"}
+	db.Insert(&user)
+	fmt.Println(user.ID)
+	// Output: 2
+}
+
+func TestInsertMany(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	models := []T{{Name: "foo"}, {Name: "bar"}}
+	mock.ExpectExec(`^insert into t \(name\) values \(\?\), \(\?\)$`).WithArgs("foo", "bar").WillReturnResult(sqlmock.NewResult(0, 2))
+	res, err := db.InsertMany(models)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	affected, err := res.RowsAffected()
+	check(t, err)
+	equals(t, int64(2), affected)
+}
+
+func TestInsertManyPtrsWithPrimary(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	models := []*T{{5, "foo"}, {6, "bar"}}
+	mock.ExpectExec(`^insert into t \(id, name\) values \(\?, \?\), \(\?, \?\)$`).WithArgs(5, "foo", 6, "bar").WillReturnResult(sqlmock.NewResult(0, 2))
+	_, err = db.InsertMany(&models)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertManyMixedPrimary(t *testing.T) {
+	db, _, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	if _, err := db.InsertMany([]T{{5, "foo"}, {Name: "bar"}}); err == nil {
+		t.Fatalf("expected err")
+	}
+}
+
+func TestInsertManyEmpty(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID int `idx:"primary"`
+	}
+	_, err = db.InsertMany([]T{})
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertManyReturning(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithDialect(gosql.PostgreSQL))
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	models := []*T{{Name: "foo"}, {Name: "bar"}}
+	mock.ExpectQuery(`^insert into t \(name\) values \(\$1\), \(\$2\) returning id$`).WithArgs("foo", "bar").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3).AddRow(4))
+	res, err := db.InsertMany(models)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	equals(t, 3, models[0].ID)
+	equals(t, 4, models[1].ID)
+	affected, err := res.RowsAffected()
+	check(t, err)
+	equals(t, int64(2), affected)
+	id, err := res.LastInsertId()
+	check(t, err)
+	equals(t, int64(4), id)
+}
+
+func TestInsertManyChunked(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithDialect(gosql.SQLite))
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	models := make([]T, 1000)
+	mock.ExpectBegin()
+	mock.ExpectExec(`^insert into t \(name\) values (\(\?\), ){998}\(\?\)$`).WillReturnResult(sqlmock.NewResult(0, 999))
+	mock.ExpectExec(`^insert into t \(name\) values \(\?\)$`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	res, err := db.InsertMany(models)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	affected, err := res.RowsAffected()
+	check(t, err)
+	equals(t, int64(1000), affected)
+}
+
+func TestTxInsertMany(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	mock.ExpectBegin()
+	mock.ExpectExec(`^insert into t \(name\) values \(\?\), \(\?\)$`).WithArgs("foo", "bar").WillReturnResult(sqlmock.NewResult(0, 2))
+	tx, err := db.Begin()
+	check(t, err)
+	_, err = tx.InsertMany([]T{{Name: "foo"}, {Name: "bar"}})
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func ExampleDB_InsertMany() {
+	os.Remove("/tmp/foo.db")
+	sqliteDB, _ := sql.Open("sqlite3", "/tmp/foo.db")
+	sqliteDB.Exec("create table user (id integer not null primary key, name text); delete from user")
+	db := gosql.New(sqliteDB, gosql.WithDialect(gosql.SQLite))
+	type User struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	users := make([]User, 2000)
+	db.InsertMany(users)
+	count, _ := db.Count("user", "*").Exec()
+	fmt.Println(count)
+	// Output: 2000
+}
+
+func TestUpdateColumns(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID       int `idx:"primary"`
+		Name     string
+		Email    string
+		IsActive bool
+	}
+	model := T{5, "foo", "foo@example.com", true}
+	mock.ExpectExec(`^update t set email = \?, is_active = \? where id = \?$`).WithArgs(model.Email, model.IsActive, model.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.UpdateColumns(&model, "email", "is_active")
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateColumnsUnknown(t *testing.T) {
+	db, _, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	if _, err := db.UpdateColumns(&T{5, "foo"}, "nope"); err == nil {
+		t.Fatalf("expected err")
+	}
+	if _, err := db.UpdateColumns(&T{5, "foo"}, "id"); err == nil {
+		t.Fatalf("expected err")
+	}
+}
+
+func TestTxUpdateColumns(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	mock.ExpectBegin()
+	mock.ExpectExec(`^update t set name = \? where id = \?$`).WithArgs("foo", 5).WillReturnResult(sqlmock.NewResult(0, 1))
+	tx, err := db.Begin()
+	check(t, err)
+	_, err = tx.UpdateColumns(&T{5, "foo"}, "name")
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateVersion(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID      int `idx:"primary"`
+		Name    string
+		Version int `lock:"version"`
+	}
+	model := T{5, "foo", 2}
+	mock.ExpectExec(`^update t set name = \?, version = version \+ 1 where id = \? and version = \?$`).WithArgs("foo", 5, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.Update(&model)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	equals(t, 3, model.Version)
+}
+
+func TestUpdateVersionStale(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID      int `idx:"primary"`
+		Name    string
+		Version uint `lock:"version"`
+	}
+	model := T{5, "foo", 2}
+	mock.ExpectBegin()
+	mock.ExpectExec(`^update t set name = \?, version = version \+ 1 where id = \? and version = \?$`).WithArgs("foo", 5, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+	tx, err := db.Begin()
+	check(t, err)
+	if _, err := tx.Update(&model); err != gosql.ErrStaleObject {
+		t.Fatalf("expected %v, got %v", gosql.ErrStaleObject, err)
+	}
+	check(t, mock.ExpectationsWereMet())
+	equals(t, uint(2), model.Version)
+}
+
+func TestUpdateColumnsVersion(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithDialect(gosql.PostgreSQL))
+	check(t, err)
+	type T struct {
+		ID      int `idx:"primary"`
+		Name    string
+		Email   string
+		Version int64 `lock:"version"`
+	}
+	model := T{5, "foo", "foo@example.com", 1}
+	mock.ExpectExec(`^update t set email = \$1, version = version \+ 1 where id = \$2 and version = \$3$`).WithArgs("foo@example.com", 5, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.UpdateColumns(&model, "email")
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	equals(t, int64(2), model.Version)
+	if _, err := db.UpdateColumns(&model, "version"); err == nil {
+		t.Fatalf("expected err")
+	}
+}
+
+func TestRegisterVersionNotInt(t *testing.T) {
+	db, _, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID      int    `idx:"primary"`
+		Version string `lock:"version"`
+	}
+	if err := db.Register(&T{}); err == nil {
+		t.Fatalf("expected err")
+	}
+}
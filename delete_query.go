@@ -1,6 +1,7 @@
 package gosql
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
@@ -9,54 +10,93 @@ import (
 // DeleteQuery is a query for deleting rows from a table.
 type DeleteQuery struct {
 	db        *DB
-	execer    Execer
+	execer    ExecerContext
 	table     string
 	joins     []string
+	joinArgs  []interface{}
 	wheres    []*where
 	whereArgs []interface{}
+	err       error
 }
 
-// Where specifies which rows will be returned.
-func (dq *DeleteQuery) Where(condition string, args ...interface{}) *DeleteQuery {
+// setErr records the first error found while building the query, which
+// is returned when the query is run.
+func (dq *DeleteQuery) setErr(err error) {
+	if dq.err == nil {
+		dq.err = err
+	}
+}
+
+// Where specifies which rows will be returned. The condition is a
+// string or an expr.Expr.
+func (dq *DeleteQuery) Where(condition interface{}, args ...interface{}) *DeleteQuery {
+	c, args, err := toCondition(condition, args)
+	dq.setErr(err)
 	w := &where{
 		conjunction: " and ",
-		condition:   condition,
+		condition:   c,
 	}
 	dq.wheres = append(dq.wheres, w)
 	dq.whereArgs = append(dq.whereArgs, args...)
 	return dq
 }
 
-// OrWhere specifies which rows will be returned.
-func (dq *DeleteQuery) OrWhere(condition string, args ...interface{}) *DeleteQuery {
+// OrWhere specifies which rows will be returned. The condition is a
+// string or an expr.Expr.
+func (dq *DeleteQuery) OrWhere(condition interface{}, args ...interface{}) *DeleteQuery {
+	c, args, err := toCondition(condition, args)
+	dq.setErr(err)
 	w := &where{
 		conjunction: " or ",
-		condition:   condition,
+		condition:   c,
 	}
 	dq.wheres = append(dq.wheres, w)
 	dq.whereArgs = append(dq.whereArgs, args...)
 	return dq
 }
 
-// Join joins another table to this query.
-func (dq *DeleteQuery) Join(join string) *DeleteQuery {
+// Join joins another table to this query. Named parameters in join are
+// bound from args.
+func (dq *DeleteQuery) Join(join string, args ...interface{}) *DeleteQuery {
+	join, args, err := bindNamed(join, args)
+	dq.setErr(err)
 	dq.joins = append(dq.joins, fmt.Sprintf(" join %s", join))
+	dq.joinArgs = append(dq.joinArgs, args...)
 	return dq
 }
 
-// LeftJoin joins another table to this query.
-func (dq *DeleteQuery) LeftJoin(join string) *DeleteQuery {
+// LeftJoin joins another table to this query. Named parameters in join
+// are bound from args.
+func (dq *DeleteQuery) LeftJoin(join string, args ...interface{}) *DeleteQuery {
+	join, args, err := bindNamed(join, args)
+	dq.setErr(err)
 	dq.joins = append(dq.joins, fmt.Sprintf(" left join %s", join))
+	dq.joinArgs = append(dq.joinArgs, args...)
 	return dq
 }
 
 // Exec executes the query.
 func (dq *DeleteQuery) Exec() (sql.Result, error) {
-	return dq.execer.Exec(dq.String(), dq.whereArgs...)
+	return dq.ExecContext(context.Background())
+}
+
+// ExecContext executes the query using the given context.
+func (dq *DeleteQuery) ExecContext(ctx context.Context) (sql.Result, error) {
+	if dq.err != nil {
+		return nil, dq.err
+	}
+	args := append(dq.joinArgs[:len(dq.joinArgs):len(dq.joinArgs)], dq.whereArgs...)
+	query, args := bind(dq.db.dialect, dq.sql(), args)
+	return dq.execer.ExecContext(ctx, query, args...)
 }
 
 // String returns the string representation of DeleteQuery.
 func (dq *DeleteQuery) String() string {
+	return rebind(dq.db.dialect, dq.sql())
+}
+
+// sql returns the query with ? placeholders.
+func (dq *DeleteQuery) sql() string {
 	var q strings.Builder
 	q.WriteString("delete from ")
 	q.WriteString(dq.table)
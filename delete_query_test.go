@@ -1,6 +1,7 @@
 package gosql_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -40,3 +41,13 @@ func TestManualDeleteJoin(t *testing.T) {
 	check(t, err)
 	check(t, mock.ExpectationsWereMet())
 }
+
+func TestManualDeleteExecContext(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	val := 1
+	mock.ExpectExec(`^delete from t where val = \?$`).WithArgs(val).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.ManualDelete("t").Where("val = ?", val).ExecContext(context.Background())
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,265 @@
+package gosql
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Dialect describes the SQL syntax differences between databases.
+// Queries are written with ? placeholders, which are rewritten to the
+// dialect's placeholders before being sent to the database.
+type Dialect interface {
+	// Placeholder returns the placeholder for the nth argument of a
+	// query, starting at 1.
+	Placeholder(n int) string
+
+	// Quote quotes an identifier, such as a table or column name, if
+	// it needs to be quoted.
+	Quote(identifier string) string
+
+	// Limit returns the clause limiting the number of rows returned by
+	// a query. A limit or offset of 0 is omitted.
+	Limit(limit int64, offset int64) string
+
+	// Returning returns the clause that makes an insert return the
+	// given columns, or an empty string if the dialect does not
+	// support one.
+	Returning(columns []string) string
+
+	// MaxPlaceholders returns the maximum number of placeholders
+	// allowed in one query.
+	MaxPlaceholders() int
+
+	// Upsert returns the clause that makes an insert update the given
+	// columns of the row it conflicts with on the conflict columns. The
+	// conflicting row is left as it is if update is empty. An empty
+	// string is returned if the dialect does not support upserts.
+	Upsert(conflict []string, update []string) string
+}
+
+// MySQL is the dialect for MySQL and MariaDB.
+var MySQL Dialect = mysqlDialect{}
+
+// PostgreSQL is the dialect for PostgreSQL.
+var PostgreSQL Dialect = postgresDialect{}
+
+// SQLite is the dialect for SQLite.
+var SQLite Dialect = sqliteDialect{}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Placeholder(int) string {
+	return "?"
+}
+
+func (mysqlDialect) Quote(identifier string) string {
+	return quoteIdentifier(identifier, "`", "`")
+}
+
+func (mysqlDialect) Limit(limit int64, offset int64) string {
+	if limit == 0 && offset > 0 {
+		// mysql does not support an offset without a limit
+		return " limit 18446744073709551615 offset " + strconv.FormatInt(offset, 10)
+	}
+	return limitOffset(limit, offset)
+}
+
+func (mysqlDialect) Returning([]string) string {
+	return ""
+}
+
+func (mysqlDialect) MaxPlaceholders() int {
+	return 65535
+}
+
+func (d mysqlDialect) Upsert(conflict []string, update []string) string {
+	var q strings.Builder
+	q.WriteString(" on duplicate key update ")
+	if len(update) == 0 {
+		// mysql has no way to do nothing, so set a column to itself
+		column := d.Quote(conflict[0])
+		q.WriteString(column)
+		q.WriteString(" = ")
+		q.WriteString(column)
+		return q.String()
+	}
+	for i, column := range update {
+		if i > 0 {
+			q.WriteString(", ")
+		}
+		column = d.Quote(column)
+		q.WriteString(column)
+		q.WriteString(" = values(")
+		q.WriteString(column)
+		q.WriteString(")")
+	}
+	return q.String()
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Placeholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+func (postgresDialect) Quote(identifier string) string {
+	return quoteIdentifier(identifier, `"`, `"`)
+}
+
+func (postgresDialect) Limit(limit int64, offset int64) string {
+	return limitOffset(limit, offset)
+}
+
+func (d postgresDialect) Returning(columns []string) string {
+	return returning(d, columns)
+}
+
+func (postgresDialect) MaxPlaceholders() int {
+	return 65535
+}
+
+func (d postgresDialect) Upsert(conflict []string, update []string) string {
+	return onConflict(d, conflict, update)
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Placeholder(int) string {
+	return "?"
+}
+
+func (sqliteDialect) Quote(identifier string) string {
+	return quoteIdentifier(identifier, `"`, `"`)
+}
+
+func (sqliteDialect) Limit(limit int64, offset int64) string {
+	if limit == 0 && offset > 0 {
+		// sqlite does not support an offset without a limit
+		return " limit -1 offset " + strconv.FormatInt(offset, 10)
+	}
+	return limitOffset(limit, offset)
+}
+
+func (sqliteDialect) Returning([]string) string {
+	return ""
+}
+
+func (sqliteDialect) MaxPlaceholders() int {
+	// the default limit of sqlite versions before 3.32.0
+	return 999
+}
+
+func (d sqliteDialect) Upsert(conflict []string, update []string) string {
+	return onConflict(d, conflict, update)
+}
+
+func limitOffset(limit int64, offset int64) string {
+	var q strings.Builder
+	if limit > 0 {
+		q.WriteString(" limit ")
+		q.WriteString(strconv.FormatInt(limit, 10))
+	}
+	if offset > 0 {
+		q.WriteString(" offset ")
+		q.WriteString(strconv.FormatInt(offset, 10))
+	}
+	return q.String()
+}
+
+func returning(d Dialect, columns []string) string {
+	var q strings.Builder
+	q.WriteString(" returning ")
+	for i, column := range columns {
+		if i > 0 {
+			q.WriteString(", ")
+		}
+		q.WriteString(d.Quote(column))
+	}
+	return q.String()
+}
+
+func onConflict(d Dialect, conflict []string, update []string) string {
+	var q strings.Builder
+	q.WriteString(" on conflict (")
+	for i, column := range conflict {
+		if i > 0 {
+			q.WriteString(", ")
+		}
+		q.WriteString(d.Quote(column))
+	}
+	if len(update) == 0 {
+		q.WriteString(") do nothing")
+		return q.String()
+	}
+	q.WriteString(") do update set ")
+	for i, column := range update {
+		if i > 0 {
+			q.WriteString(", ")
+		}
+		column = d.Quote(column)
+		q.WriteString(column)
+		q.WriteString(" = excluded.")
+		q.WriteString(column)
+	}
+	return q.String()
+}
+
+var plainIdentifier = regexp.MustCompile("^[a-z_][a-z0-9_]*$")
+
+var reservedWords = map[string]bool{
+	"all": true, "and": true, "as": true, "asc": true, "between": true,
+	"by": true, "case": true, "check": true, "column": true, "create": true,
+	"default": true, "delete": true, "desc": true, "distinct": true,
+	"drop": true, "else": true, "end": true, "exists": true, "foreign": true,
+	"from": true, "grant": true, "group": true, "having": true, "in": true,
+	"index": true, "insert": true, "into": true, "is": true, "join": true,
+	"key": true, "like": true, "limit": true, "not": true, "null": true,
+	"offset": true, "on": true, "or": true, "order": true, "primary": true,
+	"references": true, "select": true, "table": true, "then": true,
+	"to": true, "union": true, "unique": true, "update": true, "user": true,
+	"values": true, "when": true, "where": true,
+}
+
+// quoteIdentifier quotes each dot separated part of identifier that is
+// not a plain lower case identifier or that is a reserved word.
+func quoteIdentifier(identifier string, open string, close string) string {
+	parts := strings.Split(identifier, ".")
+	for i, part := range parts {
+		if plainIdentifier.MatchString(part) && !reservedWords[part] {
+			continue
+		}
+		parts[i] = open + strings.ReplaceAll(part, close, close+close) + close
+	}
+	return strings.Join(parts, ".")
+}
+
+// rebind rewrites the ? placeholders in query to the placeholders of
+// the dialect. Placeholders in quoted strings and identifiers are left
+// alone.
+func rebind(d Dialect, query string) string {
+	if d.Placeholder(1) == "?" || strings.IndexByte(query, '?') < 0 {
+		return query
+	}
+	var q strings.Builder
+	q.Grow(len(query) + 8)
+	var quote byte
+	n := 0
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '?':
+			n++
+			q.WriteString(d.Placeholder(n))
+			continue
+		}
+		q.WriteByte(c)
+	}
+	return q.String()
+}
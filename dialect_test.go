@@ -0,0 +1,147 @@
+package gosql_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/twharmon/gosql"
+)
+
+func TestPostgreSQLInsert(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithDialect(gosql.PostgreSQL))
+	check(t, err)
+	type User struct {
+		ID    int `idx:"primary"`
+		Name  string
+		Email string `col:"Email"`
+	}
+	user := User{Name: "foo", Email: "foo@example.com"}
+	mock.ExpectQuery(`^insert into "user" \(name, "Email"\) values \(\$1, \$2\) returning id$`).WithArgs(user.Name, user.Email).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	_, err = db.Insert(&user)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	equals(t, 7, user.ID)
+}
+
+func TestPostgreSQLUpdate(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithDialect(gosql.PostgreSQL))
+	check(t, err)
+	type T struct {
+		ID    int `idx:"primary"`
+		Name  string
+		Email string `idx:"primary"`
+	}
+	model := T{5, "foo", "foo@example.com"}
+	mock.ExpectExec(`^update t set name = \$1 where id = \$2 and email = \$3$`).WithArgs(model.Name, model.ID, model.Email).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.Update(&model)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgreSQLDelete(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithDialect(gosql.PostgreSQL))
+	check(t, err)
+	type T struct {
+		ID int `idx:"primary"`
+	}
+	model := T{5}
+	mock.ExpectExec(`^delete from t where id = \$1$`).WithArgs(model.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.Delete(&model)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgreSQLSelect(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithDialect(gosql.PostgreSQL))
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	rows := sqlmock.NewRows([]string{"id", "name"})
+	rows.AddRow(5, "foo")
+	mock.ExpectQuery(`^select \* from t where id > \$1 and name <> '\?' having count\(\*\) > \$2 offset 10$`).WithArgs(1, 2).WillReturnRows(rows)
+	var test []T
+	check(t, db.Select("*").Where("id > ?", 1).Where("name <> '?'").Having("count(*) > ?", 2).Offset(10).Get(&test))
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgreSQLCount(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithDialect(gosql.PostgreSQL))
+	check(t, err)
+	rows := sqlmock.NewRows([]string{"count(*)"})
+	rows.AddRow(3)
+	mock.ExpectQuery(`^select count\(\*\) from t where a = \$1 or b = \$2$`).WithArgs(1, 2).WillReturnRows(rows)
+	_, err = db.Count("t", "*").Where("a = ?", 1).OrWhere("b = ?", 2).Exec()
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgreSQLManualUpdate(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithDialect(gosql.PostgreSQL))
+	check(t, err)
+	mock.ExpectExec(`^update t set a = \$1 where b = \$2$`).WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.ManualUpdate("t").Set("a = ?", 1).Where("b = ?", 2).Exec()
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgreSQLManualDelete(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithDialect(gosql.PostgreSQL))
+	check(t, err)
+	mock.ExpectExec(`^delete from t where a = \$1$`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.ManualDelete("t").Where("a = ?", 1).Exec()
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestMySQLQuoting(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithDialect(gosql.MySQL))
+	check(t, err)
+	type Order struct {
+		ID    int    `idx:"primary"`
+		Group string `col:"group"`
+	}
+	model := Order{5, "foo"}
+	mock.ExpectExec("^update `order` set `group` = \\? where id = \\?$").WithArgs(model.Group, model.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.Update(&model)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestMySQLOffsetWithoutLimit(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithDialect(gosql.MySQL))
+	check(t, err)
+	type T struct {
+		ID int `idx:"primary"`
+	}
+	mock.ExpectQuery(`^select \* from t limit 18446744073709551615 offset 5$`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	var test []T
+	check(t, db.Select("*").Offset(5).Get(&test))
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestSQLiteDialect(t *testing.T) {
+	db := getSQLiteDB(t, "create table user (id integer not null primary key, name text, email text)")
+	type User struct {
+		ID    int `idx:"primary"`
+		Name  string
+		Email string
+	}
+	for _, name := range []string{"foo", "bar", "baz"} {
+		_, err := db.Insert(&User{Name: name, Email: name + "@example.com"})
+		check(t, err)
+	}
+	user := User{ID: 2, Name: "qux", Email: "qux@example.com"}
+	_, err := db.Update(&user)
+	check(t, err)
+	var users []User
+	check(t, db.Select("*").OrderBy("id").Offset(1).Get(&users))
+	equals(t, 2, len(users))
+	equals(t, user, users[0])
+	_, err = db.Delete(&user)
+	check(t, err)
+	count, err := db.Count("user", "*").Exec()
+	check(t, err)
+	equals(t, int64(2), count)
+}
@@ -0,0 +1,167 @@
+// Package expr builds conditions that can be passed to the Where and
+// Having methods of gosql queries in place of condition strings.
+// Conditions are written with ? placeholders, which gosql rewrites to
+// the placeholders of the dialect in use.
+package expr
+
+import (
+	"strings"
+)
+
+// Expr is a condition with its arguments.
+type Expr interface {
+	// SQL returns the condition and the arguments for its
+	// placeholders.
+	SQL() (string, []interface{})
+}
+
+type raw struct {
+	sql  string
+	args []interface{}
+}
+
+func (r raw) SQL() (string, []interface{}) {
+	return r.sql, r.args
+}
+
+// Raw returns a condition written by hand.
+func Raw(sql string, args ...interface{}) Expr {
+	return raw{sql, args}
+}
+
+func compare(column string, op string, value interface{}) Expr {
+	return raw{column + " " + op + " ?", []interface{}{value}}
+}
+
+// Eq returns the condition column = value.
+func Eq(column string, value interface{}) Expr {
+	return compare(column, "=", value)
+}
+
+// Ne returns the condition column <> value.
+func Ne(column string, value interface{}) Expr {
+	return compare(column, "<>", value)
+}
+
+// Gt returns the condition column > value.
+func Gt(column string, value interface{}) Expr {
+	return compare(column, ">", value)
+}
+
+// Ge returns the condition column >= value.
+func Ge(column string, value interface{}) Expr {
+	return compare(column, ">=", value)
+}
+
+// Lt returns the condition column < value.
+func Lt(column string, value interface{}) Expr {
+	return compare(column, "<", value)
+}
+
+// Le returns the condition column <= value.
+func Le(column string, value interface{}) Expr {
+	return compare(column, "<=", value)
+}
+
+// Like returns the condition column like pattern.
+func Like(column string, pattern string) Expr {
+	return compare(column, "like", pattern)
+}
+
+// IsNull returns the condition column is null.
+func IsNull(column string) Expr {
+	return raw{sql: column + " is null"}
+}
+
+// IsNotNull returns the condition column is not null.
+func IsNotNull(column string) Expr {
+	return raw{sql: column + " is not null"}
+}
+
+// Between returns the condition column between low and high.
+func Between(column string, low interface{}, high interface{}) Expr {
+	return raw{column + " between ? and ?", []interface{}{low, high}}
+}
+
+// In returns the condition column in (values...). It is false if there
+// are no values.
+func In(column string, values ...interface{}) Expr {
+	if len(values) == 0 {
+		return raw{sql: "1 = 0"}
+	}
+	var q strings.Builder
+	q.WriteString(column)
+	q.WriteString(" in (")
+	for i := range values {
+		if i > 0 {
+			q.WriteString(", ")
+		}
+		q.WriteString("?")
+	}
+	q.WriteString(")")
+	return raw{q.String(), values}
+}
+
+// NotIn returns the condition column not in (values...). It is true if
+// there are no values.
+func NotIn(column string, values ...interface{}) Expr {
+	return Not(In(column, values...))
+}
+
+type junction struct {
+	conjunction string
+	empty       string
+	exprs       []Expr
+}
+
+func (j junction) SQL() (string, []interface{}) {
+	if len(j.exprs) == 0 {
+		return j.empty, nil
+	}
+	if len(j.exprs) == 1 {
+		return j.exprs[0].SQL()
+	}
+	var q strings.Builder
+	var args []interface{}
+	q.WriteString("(")
+	for i, e := range j.exprs {
+		if i > 0 {
+			q.WriteString(j.conjunction)
+		}
+		sql, exprArgs := e.SQL()
+		q.WriteString(sql)
+		args = append(args, exprArgs...)
+	}
+	q.WriteString(")")
+	return q.String(), args
+}
+
+// And returns the condition that all exprs are true. It is true if
+// there are no exprs.
+func And(exprs ...Expr) Expr {
+	return junction{" and ", "1 = 1", exprs}
+}
+
+// Or returns the condition that any of exprs is true. It is false if
+// there are no exprs.
+func Or(exprs ...Expr) Expr {
+	return junction{" or ", "1 = 0", exprs}
+}
+
+type not struct {
+	expr Expr
+}
+
+func (n not) SQL() (string, []interface{}) {
+	sql, args := n.expr.SQL()
+	if j, ok := n.expr.(junction); ok && len(j.exprs) > 1 {
+		// the junction is already in parentheses
+		return "not " + sql, args
+	}
+	return "not (" + sql + ")", args
+}
+
+// Not returns the condition that expr is false.
+func Not(expr Expr) Expr {
+	return not{expr}
+}
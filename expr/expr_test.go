@@ -0,0 +1,52 @@
+package expr_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/twharmon/gosql/expr"
+)
+
+func equalsSQL(t *testing.T, e expr.Expr, sql string, args ...interface{}) {
+	gotSQL, gotArgs := e.SQL()
+	if gotSQL != sql {
+		t.Fatalf("expected %s to equal %s", gotSQL, sql)
+	}
+	if len(gotArgs) != len(args) || (len(args) > 0 && !reflect.DeepEqual(gotArgs, args)) {
+		t.Fatalf("expected %v to equal %v", gotArgs, args)
+	}
+}
+
+func TestComparisons(t *testing.T) {
+	equalsSQL(t, expr.Eq("id", 1), "id = ?", 1)
+	equalsSQL(t, expr.Ne("id", 1), "id <> ?", 1)
+	equalsSQL(t, expr.Gt("id", 1), "id > ?", 1)
+	equalsSQL(t, expr.Ge("id", 1), "id >= ?", 1)
+	equalsSQL(t, expr.Lt("id", 1), "id < ?", 1)
+	equalsSQL(t, expr.Le("id", 1), "id <= ?", 1)
+	equalsSQL(t, expr.Like("name", "a%"), "name like ?", "a%")
+	equalsSQL(t, expr.Between("age", 1, 2), "age between ? and ?", 1, 2)
+	equalsSQL(t, expr.IsNull("deleted_at"), "deleted_at is null")
+	equalsSQL(t, expr.IsNotNull("deleted_at"), "deleted_at is not null")
+	equalsSQL(t, expr.Raw("lower(name) = ?", "a"), "lower(name) = ?", "a")
+}
+
+func TestIn(t *testing.T) {
+	equalsSQL(t, expr.In("id", 1, 2, 3), "id in (?, ?, ?)", 1, 2, 3)
+	equalsSQL(t, expr.In("id"), "1 = 0")
+	equalsSQL(t, expr.NotIn("id", 1), "not (id in (?))", 1)
+	equalsSQL(t, expr.NotIn("id"), "not (1 = 0)")
+}
+
+func TestAndOr(t *testing.T) {
+	e := expr.And(expr.Eq("a", 1), expr.Or(expr.Eq("b", 2), expr.Gt("c", 3)))
+	equalsSQL(t, e, "(a = ? and (b = ? or c > ?))", 1, 2, 3)
+	equalsSQL(t, expr.And(expr.Eq("a", 1)), "a = ?", 1)
+	equalsSQL(t, expr.And(), "1 = 1")
+	equalsSQL(t, expr.Or(), "1 = 0")
+}
+
+func TestNot(t *testing.T) {
+	e := expr.Not(expr.Or(expr.Eq("a", 1), expr.IsNull("b")))
+	equalsSQL(t, e, "not (a = ? or b is null)", 1)
+}
@@ -0,0 +1,80 @@
+package gosql_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/twharmon/gosql"
+	"github.com/twharmon/gosql/expr"
+)
+
+func TestSelectWhereExpr(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithDialect(gosql.PostgreSQL))
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	mock.ExpectQuery(`^select \* from t where \(id > \$1 and \(name = \$2 or name is null\)\) limit 1$`).WithArgs(5, "foo").WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(6, "foo"))
+	var model T
+	check(t, db.Select("*").Where(expr.And(expr.Gt("id", 5), expr.Or(expr.Eq("name", "foo"), expr.IsNull("name")))).Get(&model))
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestSelectWhereExprMixed(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	mock.ExpectQuery(`^select \* from t where name = \? or id in \(\?, \?\) group by name having count\(\*\) > \?$`).WithArgs("foo", 1, 2, 3).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	var models []T
+	check(t, db.Select("*").Where("name = ?", "foo").OrWhere(expr.In("id", 1, 2)).GroupBy("name").Having(expr.Raw("count(*) > ?", 3)).Get(&models))
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestCountWhereExpr(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectQuery(`^select count\(\*\) from t where age between \? and \?$`).WithArgs(1, 2).WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(3))
+	count, err := db.Count("t", "*").Where(expr.Between("age", 1, 2)).Exec()
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	equals(t, count, int64(3))
+}
+
+func TestUpdateWhereExpr(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithDialect(gosql.PostgreSQL))
+	check(t, err)
+	mock.ExpectExec(`^update t set name = \$1 where not \(id in \(\$2, \$3\)\)$`).WithArgs("foo", 1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.ManualUpdate("t").Set("name = ?", "foo").Where(expr.NotIn("id", 1, 2)).Exec()
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteWhereExpr(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectExec(`^delete from t where name like \?$`).WithArgs("a%").WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.ManualDelete("t").Where(expr.Like("name", "a%")).Exec()
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestWhereInvalidCondition(t *testing.T) {
+	db, _, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID int `idx:"primary"`
+	}
+	var test T
+	if err := db.Select("*").Where(5).Get(&test); err == nil {
+		t.Fatal("expected err for condition that is not a string or expression")
+	} else {
+		contains(t, err.Error(), "int")
+	}
+	if _, err := db.Count("t", "*").Having(5).Exec(); err == nil {
+		t.Fatal("expected err for condition that is not a string or expression")
+	}
+}
@@ -4,6 +4,5 @@ go 1.18
 
 require (
 	github.com/DATA-DOG/go-sqlmock v1.5.0
-	github.com/go-sql-driver/mysql v1.6.0
 	github.com/mattn/go-sqlite3 v2.0.3+incompatible
 )
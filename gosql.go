@@ -3,19 +3,67 @@ package gosql
 import (
 	"database/sql"
 	"errors"
-
-	// mysql driver
-	_ "github.com/go-sql-driver/mysql"
+	"reflect"
+	"time"
 )
 
 // ErrNotFound is returned when a query for one result returns no
 // results.
 var ErrNotFound = errors.New("no result found")
 
+// ErrStaleObject is returned when updating a model with a field tagged
+// `lock:"version"` that was changed or deleted since it was loaded.
+var ErrStaleObject = errors.New("object is stale")
+
+// ErrNoValue is returned when an aggregate query over no rows is
+// scanned into a destination that can not hold null.
+var ErrNoValue = errors.New("aggregate has no value")
+
+// ErrInvalidCursor is returned when a pagination cursor can not be
+// decoded or does not match the order of the query.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Option configures a DB.
+type Option func(*DB)
+
+// WithDialect sets the SQL dialect used to build queries. The default
+// dialect is MySQL.
+func WithDialect(dialect Dialect) Option {
+	return func(db *DB) {
+		db.dialect = dialect
+	}
+}
+
+// WithClock sets the function returning the current time, which is used
+// for fields tagged `auto:"create_time"`, `auto:"update_time"` or
+// `soft:"delete"`. The default clock is time.Now.
+func WithClock(clock func() time.Time) Option {
+	return func(db *DB) {
+		db.clock = clock
+	}
+}
+
+// WithPreparedStatements makes the DB run the statements it generates
+// to insert, update, delete and restore models as prepared statements.
+// Each statement is prepared the first time it is run and kept until
+// the DB is closed. Updates of only some columns, by UpdateColumns or
+// of models with a Snapshot, are not prepared.
+func WithPreparedStatements() Option {
+	return func(db *DB) {
+		db.prepared = true
+	}
+}
+
 // New returns a reference to DB.
-func New(db *sql.DB) *DB {
-	return &DB{
-		db:     db,
-		models: make(map[string]*model),
+func New(db *sql.DB, options ...Option) *DB {
+	gdb := &DB{
+		db:      db,
+		models:  make(map[reflect.Type]*model),
+		dialect: MySQL,
+		clock:   time.Now,
+	}
+	for _, option := range options {
+		option(gdb)
 	}
+	return gdb
 }
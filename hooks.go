@@ -0,0 +1,123 @@
+package gosql
+
+import (
+	"database/sql"
+	"reflect"
+)
+
+// BeforeInserter is implemented by models that need to run code before
+// they are inserted. An error aborts the insert. The tx is nil if the
+// model is not inserted in a transaction.
+type BeforeInserter interface {
+	BeforeInsert(tx *Tx) error
+}
+
+// AfterInserter is implemented by models that need to run code after
+// they are inserted. The tx is nil if the model is not inserted in a
+// transaction.
+type AfterInserter interface {
+	AfterInsert(tx *Tx) error
+}
+
+// BeforeUpdater is implemented by models that need to run code before
+// they are updated. An error aborts the update. The tx is nil if the
+// model is not updated in a transaction.
+type BeforeUpdater interface {
+	BeforeUpdate(tx *Tx) error
+}
+
+// AfterUpdater is implemented by models that need to run code after
+// they are updated. The tx is nil if the model is not updated in a
+// transaction.
+type AfterUpdater interface {
+	AfterUpdate(tx *Tx) error
+}
+
+// BeforeDeleter is implemented by models that need to run code before
+// they are deleted. An error aborts the delete. The tx is nil if the
+// model is not deleted in a transaction.
+type BeforeDeleter interface {
+	BeforeDelete(tx *Tx) error
+}
+
+// AfterDeleter is implemented by models that need to run code after
+// they are deleted. The tx is nil if the model is not deleted in a
+// transaction.
+type AfterDeleter interface {
+	AfterDelete(tx *Tx) error
+}
+
+// AfterFinder is implemented by models that need to run code after
+// they are selected. The tx is nil if the model is not selected in a
+// transaction.
+type AfterFinder interface {
+	AfterFind(tx *Tx) error
+}
+
+type hook int
+
+const (
+	beforeInsert hook = iota
+	afterInsert
+	beforeUpdate
+	afterUpdate
+	beforeDelete
+	afterDelete
+	afterFind
+)
+
+// runHook calls the hook on v if the model implements it.
+func runHook(h hook, v reflect.Value, tx *Tx) error {
+	obj := v.Addr().Interface()
+	switch h {
+	case beforeInsert:
+		if x, ok := obj.(BeforeInserter); ok {
+			return x.BeforeInsert(tx)
+		}
+	case afterInsert:
+		if x, ok := obj.(AfterInserter); ok {
+			return x.AfterInsert(tx)
+		}
+	case beforeUpdate:
+		if x, ok := obj.(BeforeUpdater); ok {
+			return x.BeforeUpdate(tx)
+		}
+	case afterUpdate:
+		if x, ok := obj.(AfterUpdater); ok {
+			return x.AfterUpdate(tx)
+		}
+	case beforeDelete:
+		if x, ok := obj.(BeforeDeleter); ok {
+			return x.BeforeDelete(tx)
+		}
+	case afterDelete:
+		if x, ok := obj.(AfterDeleter); ok {
+			return x.AfterDelete(tx)
+		}
+	case afterFind:
+		if x, ok := obj.(AfterFinder); ok {
+			return x.AfterFind(tx)
+		}
+	}
+	return nil
+}
+
+// withHooks runs the before hook on each value, then exec, and then the
+// after hook on each value. It stops at the first error.
+func withHooks(values []reflect.Value, tx *Tx, before hook, after hook, exec func() (sql.Result, error)) (sql.Result, error) {
+	for _, v := range values {
+		if err := runHook(before, v, tx); err != nil {
+			return nil, err
+		}
+	}
+	res, err := exec()
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range values {
+		if err := runHook(after, v, tx); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
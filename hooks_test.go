@@ -0,0 +1,213 @@
+package gosql_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/twharmon/gosql"
+)
+
+type hookUser struct {
+	ID    int `idx:"primary"`
+	Email string
+	calls []string
+	txs   []*gosql.Tx
+}
+
+var errHook = errors.New("hook error")
+
+func (u *hookUser) record(name string, tx *gosql.Tx) error {
+	u.calls = append(u.calls, name)
+	u.txs = append(u.txs, tx)
+	if u.Email == "invalid" {
+		return errHook
+	}
+	return nil
+}
+
+func (u *hookUser) BeforeInsert(tx *gosql.Tx) error {
+	u.Email = strings.ToLower(u.Email)
+	return u.record("BeforeInsert", tx)
+}
+
+func (u *hookUser) AfterInsert(tx *gosql.Tx) error {
+	return u.record("AfterInsert", tx)
+}
+
+func (u *hookUser) BeforeUpdate(tx *gosql.Tx) error {
+	return u.record("BeforeUpdate", tx)
+}
+
+func (u *hookUser) AfterUpdate(tx *gosql.Tx) error {
+	return u.record("AfterUpdate", tx)
+}
+
+func (u *hookUser) BeforeDelete(tx *gosql.Tx) error {
+	return u.record("BeforeDelete", tx)
+}
+
+func (u *hookUser) AfterDelete(tx *gosql.Tx) error {
+	return u.record("AfterDelete", tx)
+}
+
+func (u *hookUser) AfterFind(tx *gosql.Tx) error {
+	return u.record("AfterFind", tx)
+}
+
+func equalCalls(t *testing.T, calls []string, expected ...string) {
+	if strings.Join(calls, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected hooks %v to be called, got %v", expected, calls)
+	}
+}
+
+func TestHooksInsert(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	user := hookUser{ID: 5, Email: "FOO@example.com"}
+	mock.ExpectExec(`^insert into hook_user \(id, email\) values \(\?, \?\)$`).WithArgs(5, "foo@example.com").WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.Insert(&user)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	equalCalls(t, user.calls, "BeforeInsert", "AfterInsert")
+	if user.txs[0] != nil {
+		t.Fatal("expected nil tx outside of a transaction")
+	}
+}
+
+func TestHooksBeforeInsertError(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	user := hookUser{ID: 5, Email: "invalid"}
+	_, err = db.Insert(&user)
+	equals(t, err, errHook)
+	check(t, mock.ExpectationsWereMet())
+	equalCalls(t, user.calls, "BeforeInsert")
+}
+
+func TestHooksInsertMany(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	users := []*hookUser{{ID: 1, Email: "A"}, {ID: 2, Email: "B"}}
+	mock.ExpectExec(`^insert into hook_user`).WithArgs(1, "a", 2, "b").WillReturnResult(sqlmock.NewResult(0, 2))
+	_, err = db.InsertMany(users)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	equalCalls(t, users[1].calls, "BeforeInsert", "AfterInsert")
+}
+
+func TestHooksUpsert(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	user := hookUser{ID: 5, Email: "FOO@example.com"}
+	mock.ExpectExec(`^insert into hook_user \(id, email\) values \(\?, \?\) on duplicate key update email = values\(email\)$`).WithArgs(5, "foo@example.com").WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.Upsert(&user, nil)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	equalCalls(t, user.calls, "BeforeInsert", "AfterInsert")
+}
+
+func TestHooksUpsertBeforeInsertErrorTx(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	user := hookUser{ID: 5, Email: "invalid"}
+	mock.ExpectBegin()
+	tx, err := db.Begin()
+	check(t, err)
+	_, err = tx.Upsert(&user, nil)
+	equals(t, err, errHook)
+	check(t, mock.ExpectationsWereMet())
+	equalCalls(t, user.calls, "BeforeInsert")
+	if user.txs[0] != tx {
+		t.Fatal("expected hooks to receive the transaction")
+	}
+}
+
+func TestHooksTx(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	user := hookUser{ID: 5, Email: "foo"}
+	mock.ExpectBegin()
+	mock.ExpectExec(`^update hook_user set email = \? where id = \?$`).WithArgs("foo", 5).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`^delete from hook_user where id = \?$`).WithArgs(5).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	tx, err := db.Begin()
+	check(t, err)
+	_, err = tx.Update(&user)
+	check(t, err)
+	_, err = tx.Delete(&user)
+	check(t, err)
+	check(t, tx.Commit())
+	check(t, mock.ExpectationsWereMet())
+	equalCalls(t, user.calls, "BeforeUpdate", "AfterUpdate", "BeforeDelete", "AfterDelete")
+	for _, hookTx := range user.txs {
+		if hookTx != tx {
+			t.Fatal("expected hooks to receive the transaction")
+		}
+	}
+}
+
+func TestHooksBeforeUpdateError(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	user := hookUser{ID: 5, Email: "invalid"}
+	_, err = db.UpdateColumns(&user, "email")
+	equals(t, err, errHook)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestHooksBeforeDeleteError(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	user := hookUser{ID: 5, Email: "invalid"}
+	_, err = db.HardDelete(&user)
+	equals(t, err, errHook)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestHooksAfterFind(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectQuery(`^select \* from hook_user limit 1$`).WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(5, "foo"))
+	var user hookUser
+	check(t, db.Select("*").Get(&user))
+	check(t, mock.ExpectationsWereMet())
+	equalCalls(t, user.calls, "AfterFind")
+}
+
+func TestHooksAfterFindMany(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectQuery(`^select \* from hook_user$`).WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(5, "foo").AddRow(6, "bar"))
+	var users []hookUser
+	check(t, db.Select("*").Get(&users))
+	check(t, mock.ExpectationsWereMet())
+	equalCalls(t, users[1].calls, "AfterFind")
+}
+
+func TestHooksAfterFindManyPtrsTx(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^select \* from hook_user$`).WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(5, "foo"))
+	mock.ExpectCommit()
+	tx, err := db.Begin()
+	check(t, err)
+	var users []*hookUser
+	check(t, tx.Select("*").Get(&users))
+	check(t, tx.Commit())
+	check(t, mock.ExpectationsWereMet())
+	equalCalls(t, users[0].calls, "AfterFind")
+	if users[0].txs[0] != tx {
+		t.Fatal("expected AfterFind to receive the transaction")
+	}
+}
+
+func TestHooksAfterFindError(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectQuery(`^select \* from hook_user$`).WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(5, "invalid"))
+	var users []*hookUser
+	equals(t, db.Select("*").Get(&users), errHook)
+}
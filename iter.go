@@ -0,0 +1,156 @@
+package gosql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// Iter streams the rows of a select query one at a time, so large
+// results do not need to fit in memory. It is used like sql.Rows:
+//
+//	it := db.Select("*").Iter((*User)(nil))
+//	defer it.Close()
+//	for it.Next() {
+//	    var user User
+//	    if err := it.Scan(&user); err != nil {
+//	        return err
+//	    }
+//	}
+//	return it.Err()
+type Iter struct {
+	sq            *SelectQuery
+	rows          *sql.Rows
+	fieldIndecies []int
+	dests         []interface{}
+	err           error
+}
+
+// Iter runs the query and returns an iterator over its rows. obj is a
+// pointer to a struct of the type the rows are scanned into, which may
+// be nil. Errors are reported by Err.
+func (sq *SelectQuery) Iter(obj interface{}) *Iter {
+	return sq.IterContext(context.Background(), obj)
+}
+
+// IterContext is like Iter, but uses the given context for the query.
+func (sq *SelectQuery) IterContext(ctx context.Context, obj interface{}) *Iter {
+	it := &Iter{sq: sq}
+	it.err = it.start(ctx, obj)
+	return it
+}
+
+func (it *Iter) start(ctx context.Context, obj interface{}) error {
+	sq := it.sq
+	if sq.err != nil {
+		return sq.err
+	}
+	if len(sq.preloads) > 0 {
+		return errors.New("relations can not be preloaded while iterating")
+	}
+	t := reflect.TypeOf(obj)
+	if t == nil || t.Kind() != reflect.Ptr || !isStructTarget(t.Elem()) {
+		return fmt.Errorf("obj must be a pointer to a struct (%s found)", t)
+	}
+	if err := sq.setModel(t.Elem()); err != nil {
+		return err
+	}
+	sq.many = true
+	rows, err := sq.query(ctx)
+	if err != nil {
+		return err
+	}
+	it.rows = rows
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	if it.fieldIndecies, err = sq.model.getScanFieldIndecies(columns); err != nil {
+		return err
+	}
+	it.dests = make([]interface{}, len(columns))
+	return nil
+}
+
+// Next prepares the next row for Scan. It returns false when there are
+// no more rows or an error occurred, which is reported by Err.
+func (it *Iter) Next() bool {
+	if it.err != nil || it.rows == nil {
+		return false
+	}
+	if it.rows.Next() {
+		return true
+	}
+	it.err = it.rows.Err()
+	return false
+}
+
+// Scan scans the current row into out, which must be a pointer to a
+// struct of the type given to Iter.
+func (it *Iter) Scan(out interface{}) error {
+	if it.err != nil {
+		return it.err
+	}
+	if it.rows == nil {
+		return errors.New("no row to scan")
+	}
+	m := it.sq.model
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Type().Elem() != m.typ {
+		return fmt.Errorf("out must be a non nil pointer to %s", m.typ)
+	}
+	e := v.Elem()
+	for j, i := range it.fieldIndecies {
+		it.dests[j] = m.fieldAddr(e, i)
+	}
+	if err := it.rows.Scan(it.dests...); err != nil {
+		return err
+	}
+	m.takeSnapshot(e)
+	return runHook(afterFind, e, it.sq.tx)
+}
+
+// Err returns the error, if any, that occurred while iterating.
+func (it *Iter) Err() error {
+	return it.err
+}
+
+// Close closes the iterator. It is safe to call more than once.
+func (it *Iter) Close() error {
+	if it.rows == nil {
+		return nil
+	}
+	return it.rows.Close()
+}
+
+// Each runs the query and calls fn with each row, one at a time. fn
+// must be a func(*T) error, where T is a struct the rows are scanned
+// into. Each stops and returns the error if fn returns one.
+func (sq *SelectQuery) Each(fn interface{}) error {
+	return sq.EachContext(context.Background(), fn)
+}
+
+// EachContext is like Each, but uses the given context for the query.
+func (sq *SelectQuery) EachContext(ctx context.Context, fn interface{}) error {
+	f := reflect.ValueOf(fn)
+	t := reflect.TypeOf(fn)
+	if t == nil || t.Kind() != reflect.Func || t.NumIn() != 1 || t.NumOut() != 1 || t.Out(0) != errorType {
+		return fmt.Errorf("fn must be a func(*T) error (%s found)", t)
+	}
+	it := sq.IterContext(ctx, reflect.Zero(t.In(0)).Interface())
+	defer it.Close()
+	for it.Next() {
+		out := reflect.New(t.In(0).Elem())
+		if err := it.Scan(out.Interface()); err != nil {
+			return err
+		}
+		if err, _ := f.Call([]reflect.Value{out})[0].Interface().(error); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
@@ -0,0 +1,147 @@
+package gosql_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestSelectQueryIter(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	check(t, db.Register(T{}))
+	rows := sqlmock.NewRows([]string{"id", "name"})
+	rows.AddRow(1, "foo")
+	rows.AddRow(2, "bar")
+	mock.ExpectQuery(`^select \* from t where id > \?$`).WithArgs(0).WillReturnRows(rows)
+	it := db.Select("*").Where("id > ?", 0).Iter((*T)(nil))
+	defer it.Close()
+	var names []string
+	for it.Next() {
+		var test T
+		check(t, it.Scan(&test))
+		names = append(names, test.Name)
+	}
+	check(t, it.Err())
+	check(t, mock.ExpectationsWereMet())
+	equals(t, 2, len(names))
+	equals(t, "foo", names[0])
+	equals(t, "bar", names[1])
+}
+
+func TestSelectQueryIterErr(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID int `idx:"primary"`
+	}
+	check(t, db.Register(T{}))
+	queryErr := errors.New("foo")
+	mock.ExpectQuery(`^select \* from t$`).WillReturnError(queryErr)
+	it := db.Select("*").Iter((*T)(nil))
+	defer it.Close()
+	equals(t, false, it.Next())
+	equals(t, queryErr, it.Err())
+}
+
+func TestSelectQueryIterNotStruct(t *testing.T) {
+	db, _, err := getMockDB()
+	check(t, err)
+	it := db.Select("*").Iter(new(int))
+	contains(t, it.Err().Error(), "struct")
+}
+
+func TestSelectQueryIterScanWrongType(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID int `idx:"primary"`
+	}
+	type U struct {
+		ID int `idx:"primary"`
+	}
+	check(t, db.Register(T{}))
+	mock.ExpectQuery(`^select \* from t$`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	it := db.Select("*").Iter((*T)(nil))
+	defer it.Close()
+	equals(t, true, it.Next())
+	contains(t, it.Scan(&U{}).Error(), "pointer")
+}
+
+func TestSelectQueryEach(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID int `idx:"primary"`
+	}
+	check(t, db.Register(T{}))
+	rows := sqlmock.NewRows([]string{"id"})
+	for i := 0; i < 5; i++ {
+		rows.AddRow(i)
+	}
+	mock.ExpectQuery(`^select \* from t$`).WillReturnRows(rows)
+	sum := 0
+	check(t, db.Select("*").Each(func(test *T) error {
+		sum += test.ID
+		return nil
+	}))
+	check(t, mock.ExpectationsWereMet())
+	equals(t, 10, sum)
+}
+
+func TestSelectQueryEachStop(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID int `idx:"primary"`
+	}
+	check(t, db.Register(T{}))
+	rows := sqlmock.NewRows([]string{"id"})
+	for i := 0; i < 5; i++ {
+		rows.AddRow(i)
+	}
+	mock.ExpectQuery(`^select \* from t$`).WillReturnRows(rows)
+	stop := errors.New("stop")
+	n := 0
+	err = db.Select("*").Each(func(test *T) error {
+		n++
+		if test.ID == 1 {
+			return stop
+		}
+		return nil
+	})
+	equals(t, stop, err)
+	equals(t, 2, n)
+}
+
+func TestSelectQueryEachBadFunc(t *testing.T) {
+	db, _, err := getMockDB()
+	check(t, err)
+	contains(t, db.Select("*").Each(func(int) {}).Error(), "func(*T) error")
+	contains(t, db.Select("*").Each(nil).Error(), "func(*T) error")
+}
+
+func TestSelectQueryEachSQLite(t *testing.T) {
+	db := getSQLiteDB(t, "create table t (id integer not null primary key, name text not null)")
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	check(t, db.Register(T{}))
+	for i := 1; i <= 3; i++ {
+		_, err := db.Insert(&T{ID: i, Name: "foo"})
+		check(t, err)
+	}
+	n := 0
+	check(t, db.Select("*").OrderBy("id").Each(func(test *T) error {
+		n++
+		equals(t, n, test.ID)
+		return nil
+	}))
+	equals(t, 3, n)
+}
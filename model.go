@@ -1,16 +1,240 @@
 package gosql
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
 	"reflect"
 	"strings"
+	"sync"
+	"time"
+	"unsafe"
 )
 
 type model struct {
-	name                 string
-	table                string
-	typ                  reflect.Type
-	fields               []string
+	name    string
+	table   string
+	typ     reflect.Type
+	dialect Dialect
+	fields  []*field
+
+	// columns holds the index in fields of the first field for each
+	// column.
+	columns map[string]int
+
+	// primaryFieldIndecies holds the indecies in fields of the primary
+	// fields.
 	primaryFieldIndecies []int
+
+	// snapshotIndex is the index sequence of the model's Snapshot, or
+	// nil if it has none.
+	snapshotIndex []int
+
+	// versionFieldIndex is the index in fields of the field tagged
+	// `lock:"version"`, or -1 if there is none.
+	versionFieldIndex int
+
+	// softDeleteFieldIndex is the index in fields of the field tagged
+	// `soft:"delete"`, or -1 if there is none.
+	softDeleteFieldIndex int
+
+	// createTimeFieldIndex and updateTimeFieldIndex are the indecies in
+	// fields of the fields tagged `auto:"create_time"` and
+	// `auto:"update_time"`, or -1 if there are none.
+	createTimeFieldIndex int
+	updateTimeFieldIndex int
+
+	// relations are the fields tagged `rel:"..."`.
+	relations []*relation
+
+	// updateAllKey is the updateKey of the statement updating all the
+	// fields that Update writes when the model has no snapshot.
+	updateAllKey updateKey
+
+	// queries caches the statements generated for the model by an
+	// insertKey, updateKey, deleteKey, softDeleteKey or restoreKey.
+	queries sync.Map
+}
+
+// field maps a column to a struct field of the model.
+type field struct {
+	column string
+
+	// index is the index sequence of the struct field, which is longer
+	// than one for fields of embedded structs.
+	index []int
+	typ   reflect.Type
+
+	// offset is the offset of the field in the model struct. It is only
+	// valid if direct is set, which it is unless the field is in an
+	// embedded pointer.
+	offset uintptr
+	direct bool
+}
+
+// newModel returns the model of the struct type typ. The model is not
+// validated.
+func newModel(typ reflect.Type, dialect Dialect) (*model, error) {
+	m := new(model)
+	m.typ = typ
+	m.dialect = dialect
+	m.name = m.typ.Name()
+	m.table = toSnakeCase(m.name)
+	m.versionFieldIndex = -1
+	m.softDeleteFieldIndex = -1
+	m.createTimeFieldIndex = -1
+	m.updateTimeFieldIndex = -1
+	if err := m.addFields(m.typ, nil, 0, true); err != nil {
+		return nil, err
+	}
+	m.columns = make(map[string]int, len(m.fields))
+	for i, f := range m.fields {
+		if _, ok := m.columns[f.column]; !ok {
+			m.columns[f.column] = i
+		}
+	}
+	var updateAll []int
+	for i := range m.fields {
+		if !isIntIn(i, m.primaryFieldIndecies) && i != m.versionFieldIndex && i != m.createTimeFieldIndex {
+			updateAll = append(updateAll, i)
+		}
+	}
+	m.updateAllKey = newUpdateKey(updateAll)
+	return m, nil
+}
+
+// addFields adds the fields of the struct type typ to m. Fields of
+// anonymous embedded structs are added as if they were fields of the
+// model. typ is at offset in the model struct, which is only valid if
+// direct is set.
+func (m *model) addFields(typ reflect.Type, index []int, offset uintptr, direct bool) error {
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.Tag.Get("col") == "-" {
+			continue
+		}
+		fIndex := append(append([]int(nil), index...), i)
+		if tag, ok := f.Tag.Lookup("rel"); ok {
+			if err := m.addRelation(f, fIndex, tag); err != nil {
+				return err
+			}
+			continue
+		}
+		if f.Type == snapshotType {
+			m.snapshotIndex = fIndex
+			continue
+		}
+		if f.Anonymous && !isSupportedType(f.Type) {
+			t := f.Type
+			embedDirect := direct
+			if t.Kind() == reflect.Ptr {
+				if !f.IsExported() {
+					// reflect can not allocate unexported embedded pointers
+					continue
+				}
+				t = t.Elem()
+				embedDirect = false
+			}
+			if t.Kind() == reflect.Struct {
+				if err := m.addFields(t, fIndex, offset+f.Offset, embedDirect); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		column := toSnakeCase(f.Name)
+		if tag, ok := f.Tag.Lookup("col"); ok {
+			column = tag
+		}
+		if !isSupportedType(f.Type) {
+			return fmt.Errorf("model %s field %s has unsupported type %s", m.name, f.Name, f.Type)
+		}
+		if tag, ok := f.Tag.Lookup("idx"); ok && tag == "primary" {
+			m.primaryFieldIndecies = append(m.primaryFieldIndecies, len(m.fields))
+		}
+		if tag, ok := f.Tag.Lookup("lock"); ok && tag == "version" {
+			if m.versionFieldIndex >= 0 {
+				return fmt.Errorf("model %s must have at most one field tagged `lock:\"version\"`", m.name)
+			}
+			if !isIntKind(f.Type.Kind()) {
+				return fmt.Errorf("model %s field %s tagged `lock:\"version\"` must be an integer", m.name, f.Name)
+			}
+			m.versionFieldIndex = len(m.fields)
+		}
+		if tag, ok := f.Tag.Lookup("soft"); ok && tag == "delete" {
+			if m.softDeleteFieldIndex >= 0 {
+				return fmt.Errorf("model %s must have at most one field tagged `soft:\"delete\"`", m.name)
+			}
+			if f.Type != nullTimeType {
+				return fmt.Errorf("model %s field %s tagged `soft:\"delete\"` must be a NullTime", m.name, f.Name)
+			}
+			m.softDeleteFieldIndex = len(m.fields)
+		}
+		if tag, ok := f.Tag.Lookup("auto"); ok {
+			var index *int
+			switch tag {
+			case "create_time":
+				index = &m.createTimeFieldIndex
+			case "update_time":
+				index = &m.updateTimeFieldIndex
+			default:
+				return fmt.Errorf("model %s field %s has unknown auto tag %s", m.name, f.Name, tag)
+			}
+			if *index >= 0 {
+				return fmt.Errorf("model %s must have at most one field tagged `auto:\"%s\"`", m.name, tag)
+			}
+			if !isAutoTimeType(f.Type) {
+				return fmt.Errorf("model %s field %s tagged `auto:\"%s\"` must be a time.Time, *time.Time or NullTime", m.name, f.Name, tag)
+			}
+			*index = len(m.fields)
+		}
+		m.fields = append(m.fields, &field{
+			column: column,
+			index:  fIndex,
+			typ:    f.Type,
+			offset: offset + f.Offset,
+			direct: direct,
+		})
+	}
+	return nil
+}
+
+// fieldValue returns the struct field of v for the field at index i of
+// m.fields. If the field is in a nil embedded pointer, the zero value
+// of the field is returned.
+func (m *model) fieldValue(v reflect.Value, i int) reflect.Value {
+	f := m.fields[i]
+	for j, x := range f.index {
+		if j > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Zero(f.typ)
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// settableField returns the struct field of v for the field at index i
+// of m.fields, allocating nil embedded pointers on the way.
+func (m *model) settableField(v reflect.Value, i int) reflect.Value {
+	return settableByIndex(v, m.fields[i].index)
+}
+
+// fieldAddr returns a pointer to the struct field of v, which must be
+// addressable, for the field at index i of m.fields. Fields that are
+// not in embedded pointers are found by their offset without walking
+// the struct, which does not allocate.
+func (m *model) fieldAddr(v reflect.Value, i int) interface{} {
+	f := m.fields[i]
+	if !f.direct {
+		return m.settableField(v, i).Addr().Interface()
+	}
+	return reflect.NewAt(f.typ, unsafe.Add(unsafe.Pointer(v.UnsafeAddr()), f.offset)).Interface()
 }
 
 func isIntIn(i int, arr []int) bool {
@@ -22,115 +246,507 @@ func isIntIn(i int, arr []int) bool {
 	return false
 }
 
+// executor can execute queries. It is implemented by sql.DB and sql.Tx.
+type executor interface {
+	ExecerContext
+	QuerierContext
+	QueryRowerContext
+}
+
+// insert inserts v and sets the primary field generated by the database,
+// if there is one. The automatic time fields are set to now.
+func (m *model) insert(ctx context.Context, e executor, v reflect.Value, now time.Time) (sql.Result, error) {
+	m.setInsertTimes(v, now)
+	query := m.getInsertQuery(v)
+	args := m.getArgs(v)
+	generated := m.getGeneratedFieldIndex(v)
+	if generated < 0 {
+		res, err := e.ExecContext(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+		m.takeSnapshot(v)
+		return res, nil
+	}
+	f := m.settableField(v, generated)
+	if returning := m.dialect.Returning([]string{m.fields[generated].column}); returning != "" {
+		if err := e.QueryRowContext(ctx, query+returning, args...).Scan(f.Addr().Interface()); err != nil {
+			return nil, err
+		}
+		m.takeSnapshot(v)
+		return insertResult{f, 1}, nil
+	}
+	res, err := e.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	// drivers that can not report the id return an error, in which case
+	// the field is left as it is
+	if id, err := res.LastInsertId(); err == nil {
+		setInt(f, id)
+	}
+	m.takeSnapshot(v)
+	return res, nil
+}
+
+// getGeneratedFieldIndex returns the index of the primary field that
+// the database will generate on insert, or -1 if there is none. A field
+// is generated if it is the only zero primary field.
+func (m *model) getGeneratedFieldIndex(v reflect.Value) int {
+	generated := -1
+	for _, i := range m.primaryFieldIndecies {
+		if !m.fieldValue(v, i).IsZero() {
+			continue
+		}
+		if generated >= 0 {
+			return -1
+		}
+		generated = i
+	}
+	return generated
+}
+
+// setInt sets f to i if f is an integer.
+func setInt(f reflect.Value, i int64) {
+	switch f.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f.SetUint(uint64(i))
+	}
+}
+
+func isIntKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// insertResult is the result of an insert that returned the generated
+// primary field of the last row inserted.
+type insertResult struct {
+	primary reflect.Value
+	rows    int64
+}
+
+// LastInsertId returns the generated primary field if it is an integer.
+func (r insertResult) LastInsertId() (int64, error) {
+	switch r.primary.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return r.primary.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(r.primary.Uint()), nil
+	}
+	return 0, fmt.Errorf("primary field of type %s is not an integer", r.primary.Type())
+}
+
+// RowsAffected returns the number of rows inserted.
+func (r insertResult) RowsAffected() (int64, error) {
+	return r.rows, nil
+}
+
+// emptyResult is the result of a query that did not need to be run.
+type emptyResult struct{}
+
+// LastInsertId returns 0.
+func (emptyResult) LastInsertId() (int64, error) {
+	return 0, nil
+}
+
+// RowsAffected returns 0.
+func (emptyResult) RowsAffected() (int64, error) {
+	return 0, nil
+}
+
 func (m *model) getInsertQuery(v reflect.Value) string {
+	return m.getCachedInsert(v).query
+}
+
+// getInsertFieldIndecies returns the indecies of the fields that are
+// inserted for v. Zero primary fields are left out so the database can
+// generate them.
+func (m *model) getInsertFieldIndecies(v reflect.Value) []int {
+	var indecies []int
+	for i := 0; i < len(m.fields); i++ {
+		if isIntIn(i, m.primaryFieldIndecies) && m.fieldValue(v, i).IsZero() {
+			continue
+		}
+		indecies = append(indecies, i)
+	}
+	return indecies
+}
+
+// getInsertManyQuery returns a query inserting rows rows of the fields
+// at the given indecies.
+func (m *model) getInsertManyQuery(fieldIndecies []int, rows int) string {
 	var query strings.Builder
-	var values strings.Builder
 	query.WriteString("insert into ")
-	query.WriteString(m.table)
+	query.WriteString(m.dialect.Quote(m.table))
 	query.WriteString(" (")
+	for i, index := range fieldIndecies {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString(m.dialect.Quote(m.fields[index].column))
+	}
+	query.WriteString(") values ")
+	n := 0
+	for r := 0; r < rows; r++ {
+		if r > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString("(")
+		for i := range fieldIndecies {
+			if i > 0 {
+				query.WriteString(", ")
+			}
+			n++
+			query.WriteString(m.dialect.Placeholder(n))
+		}
+		query.WriteString(")")
+	}
+	return query.String()
+}
+
+// getInsertManyFieldIndecies returns the indecies of the fields that
+// are inserted for all of the values. A primary field must be zero in
+// all or none of the values.
+func (m *model) getInsertManyFieldIndecies(values []reflect.Value) ([]int, error) {
+	var indecies []int
 	for i := 0; i < len(m.fields); i++ {
-		if isIntIn(i, m.primaryFieldIndecies) && v.Field(i).IsZero() {
-			if i == len(m.fields)-1 {
-				query.WriteString(") ")
-				values.WriteString(")")
+		if isIntIn(i, m.primaryFieldIndecies) {
+			zeros := 0
+			for _, v := range values {
+				if m.fieldValue(v, i).IsZero() {
+					zeros++
+				}
+			}
+			if zeros == len(values) {
+				continue
+			}
+			if zeros > 0 {
+				return nil, fmt.Errorf("primary field for column %s must be zero in all or none of the %s models", m.fields[i].column, m.name)
+			}
+		}
+		indecies = append(indecies, i)
+	}
+	return indecies, nil
+}
+
+// insertMany inserts the values in as few queries as the dialect's
+// placeholder limit allows and sets the primary fields generated by the
+// database if the dialect can return them. The automatic time fields
+// are set to now.
+func (m *model) insertMany(ctx context.Context, e executor, values []reflect.Value, now time.Time) (sql.Result, error) {
+	var res batchResult
+	if len(values) == 0 {
+		return res, nil
+	}
+	for _, v := range values {
+		m.setInsertTimes(v, now)
+	}
+	fieldIndecies, err := m.getInsertManyFieldIndecies(values)
+	if err != nil {
+		return nil, err
+	}
+	generated := m.getGeneratedFieldIndex(values[0])
+	returning := ""
+	if generated >= 0 {
+		returning = m.dialect.Returning([]string{m.fields[generated].column})
+	}
+	size := m.getInsertManyChunkSize(len(fieldIndecies))
+	for start := 0; start < len(values); start += size {
+		end := start + size
+		if end > len(values) {
+			end = len(values)
+		}
+		chunk := values[start:end]
+		query := m.getInsertManyQuery(fieldIndecies, len(chunk))
+		args := make([]interface{}, 0, len(chunk)*len(fieldIndecies))
+		for _, v := range chunk {
+			for _, i := range fieldIndecies {
+				args = append(args, m.fieldValue(v, i).Interface())
 			}
-			continue
 		}
-		query.WriteString(m.fields[i])
-		values.WriteString("?")
-		if i == len(m.fields)-1 {
-			query.WriteString(") ")
-			values.WriteString(")")
+		if returning == "" {
+			r, err := e.ExecContext(ctx, query, args...)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, r)
 			continue
 		}
-		if !isIntIn(i+1, m.primaryFieldIndecies) || !v.Field(i+1).IsZero() {
-			query.WriteString(", ")
-			values.WriteString(", ")
+		n, err := m.scanReturning(ctx, e, query+returning, args, chunk, generated)
+		if err != nil {
+			return nil, err
 		}
+		res = append(res, insertResult{m.fieldValue(chunk[len(chunk)-1], generated), n})
 	}
-	query.WriteString("values (")
-	query.WriteString(values.String())
-	return query.String()
+	for _, v := range values {
+		m.takeSnapshot(v)
+	}
+	return res, nil
+}
+
+// scanReturning runs an insert query returning the generated primary
+// field, scans the returned rows into the values in order and returns
+// the number of rows returned.
+func (m *model) scanReturning(ctx context.Context, q QuerierContext, query string, args []interface{}, values []reflect.Value, generated int) (int64, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	i := 0
+	for rows.Next() {
+		if i == len(values) {
+			return 0, fmt.Errorf("insert returned more than %d rows", len(values))
+		}
+		if err := rows.Scan(m.fieldAddr(values[i], generated)); err != nil {
+			return 0, err
+		}
+		i++
+	}
+	return int64(i), rows.Err()
+}
+
+// getInsertManyChunkSize returns the number of rows of fieldCount
+// fields that can be inserted in one query.
+func (m *model) getInsertManyChunkSize(fieldCount int) int {
+	if fieldCount == 0 {
+		return 1
+	}
+	size := m.dialect.MaxPlaceholders() / fieldCount
+	if size < 1 {
+		return 1
+	}
+	return size
+}
+
+// batchResult is the result of an insert run as several queries.
+type batchResult []sql.Result
+
+// LastInsertId returns the id reported for the last query.
+func (r batchResult) LastInsertId() (int64, error) {
+	if len(r) == 0 {
+		return 0, nil
+	}
+	return r[len(r)-1].LastInsertId()
+}
+
+// RowsAffected returns the total number of rows affected by the
+// queries.
+func (r batchResult) RowsAffected() (int64, error) {
+	var total int64
+	for _, res := range r {
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
 }
 
 func (m *model) getDeleteQuery() string {
+	return m.getCachedQuery(deleteKey{}, m.buildDeleteQuery)
+}
+
+func (m *model) buildDeleteQuery() string {
 	var query strings.Builder
 	query.WriteString("delete from ")
-	query.WriteString(m.table)
-	query.WriteString(" where ")
-	for i, index := range m.primaryFieldIndecies {
-		query.WriteString(m.fields[index])
-		query.WriteString(" = ?")
-		if i < len(m.primaryFieldIndecies)-1 {
-			query.WriteString(" and ")
+	query.WriteString(m.dialect.Quote(m.table))
+	m.writePrimaryWhere(&query, 0)
+	return query.String()
+}
+
+// update updates the fields of v at the given indecies. The field
+// tagged `auto:"update_time"` is set to now and updated as well.
+func (m *model) update(ctx context.Context, e ExecerContext, v reflect.Value, fieldIndecies []int, now time.Time) (sql.Result, error) {
+	if len(fieldIndecies) == 0 {
+		return emptyResult{}, nil
+	}
+	fieldIndecies = m.setUpdateTime(v, now, fieldIndecies)
+	e = m.updateExecutor(e, fieldIndecies)
+	res, err := e.ExecContext(ctx, m.getUpdateQuery(fieldIndecies), m.getUpdateArgs(v, fieldIndecies)...)
+	if err != nil {
+		return nil, err
+	}
+	if m.versionFieldIndex >= 0 {
+		n, err := res.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return nil, ErrStaleObject
+		}
+		f := m.settableField(v, m.versionFieldIndex)
+		if f.CanInt() {
+			f.SetInt(f.Int() + 1)
+		} else {
+			f.SetUint(f.Uint() + 1)
 		}
+		fieldIndecies = append(fieldIndecies[:len(fieldIndecies):len(fieldIndecies)], m.versionFieldIndex)
 	}
-	return query.String()
+	m.refreshSnapshot(v, fieldIndecies)
+	return res, nil
 }
 
-func (m *model) getUpdateQuery() string {
-	var query strings.Builder
-	query.WriteString("update ")
-	query.WriteString(m.table)
-	query.WriteString(" set ")
+// getUpdateFieldIndecies returns the indecies of the fields that are
+// updated by Update. If the model has a snapshot, only the fields that
+// changed are updated.
+func (m *model) getUpdateFieldIndecies(v reflect.Value) []int {
+	snapshot := m.getSnapshot(v)
+	var indecies []int
 	for i := 0; i < len(m.fields); i++ {
-		if isIntIn(i, m.primaryFieldIndecies) {
+		if isIntIn(i, m.primaryFieldIndecies) || i == m.versionFieldIndex || i == m.createTimeFieldIndex {
+			continue
+		}
+		if snapshot != nil && !snapshot.changed(m.fieldValue(v, i), i) {
 			continue
 		}
-		query.WriteString(m.fields[i])
-		query.WriteString(" = ?")
-		if i < len(m.fields)-1 && !isIntIn(i+1, m.primaryFieldIndecies) {
+		indecies = append(indecies, i)
+	}
+	return indecies
+}
+
+// getFieldIndeciesByColumns returns the indecies of the fields for the
+// given columns, which must not be primary columns.
+func (m *model) getFieldIndeciesByColumns(columns []string) ([]int, error) {
+	indecies := make([]int, len(columns))
+	for j, column := range columns {
+		i := m.getFieldIndexByColumn(column)
+		if i < 0 {
+			return nil, fmt.Errorf("model %s has no field for column %s", m.name, column)
+		}
+		if isIntIn(i, m.primaryFieldIndecies) {
+			return nil, fmt.Errorf("primary column %s of model %s can not be updated", column, m.name)
+		}
+		if i == m.versionFieldIndex {
+			return nil, fmt.Errorf("version column %s of model %s can not be updated", column, m.name)
+		}
+		indecies[j] = i
+	}
+	return indecies, nil
+}
+
+func (m *model) getUpdateQuery(fieldIndecies []int) string {
+	return m.getCachedQuery(newUpdateKey(fieldIndecies), func() string {
+		return m.buildUpdateQuery(fieldIndecies)
+	})
+}
+
+func (m *model) buildUpdateQuery(fieldIndecies []int) string {
+	var query strings.Builder
+	query.WriteString("update ")
+	query.WriteString(m.dialect.Quote(m.table))
+	query.WriteString(" set ")
+	for n, i := range fieldIndecies {
+		if n > 0 {
 			query.WriteString(", ")
 		}
+		query.WriteString(m.dialect.Quote(m.fields[i].column))
+		query.WriteString(" = ")
+		query.WriteString(m.dialect.Placeholder(n + 1))
+	}
+	if m.versionFieldIndex >= 0 {
+		version := m.dialect.Quote(m.fields[m.versionFieldIndex].column)
+		query.WriteString(", ")
+		query.WriteString(version)
+		query.WriteString(" = ")
+		query.WriteString(version)
+		query.WriteString(" + 1")
+	}
+	m.writePrimaryWhere(&query, len(fieldIndecies))
+	if m.versionFieldIndex >= 0 {
+		query.WriteString(" and ")
+		query.WriteString(m.dialect.Quote(m.fields[m.versionFieldIndex].column))
+		query.WriteString(" = ")
+		query.WriteString(m.dialect.Placeholder(len(fieldIndecies) + len(m.primaryFieldIndecies) + 1))
 	}
+	return query.String()
+}
+
+// writePrimaryWhere writes a where clause matching the primary fields.
+// The placeholders are numbered starting after n.
+func (m *model) writePrimaryWhere(query *strings.Builder, n int) {
 	query.WriteString(" where ")
 	for i, index := range m.primaryFieldIndecies {
-		query.WriteString(m.fields[index])
-		query.WriteString(" = ?")
-		if i < len(m.primaryFieldIndecies)-1 {
+		if i > 0 {
 			query.WriteString(" and ")
 		}
+		n++
+		query.WriteString(m.dialect.Quote(m.fields[index].column))
+		query.WriteString(" = ")
+		query.WriteString(m.dialect.Placeholder(n))
 	}
-	return query.String()
 }
 
+// getFieldIndexByColumn returns the index of the field for column, or
+// -1 if there is none.
+func (m *model) getFieldIndexByColumn(column string) int {
+	if i, ok := m.columns[column]; ok {
+		return i
+	}
+	return -1
+}
+
+// getFieldIndexByName returns the index of the field for the column
+// name, which may be qualified by a table, or -1 if there is none.
 func (m *model) getFieldIndexByName(name string) int {
-	for i, f := range m.fields {
-		if name == f || strings.HasSuffix(name, "."+f) {
-			return i
-		}
+	if i, ok := m.columns[name]; ok {
+		return i
+	}
+	if dot := strings.LastIndexByte(name, '.'); dot >= 0 {
+		return m.getFieldIndexByColumn(name[dot+1:])
 	}
 	return -1
 }
 
-func (m *model) getArgs(v reflect.Value) []interface{} {
-	var args []interface{}
-	for i := 0; i < len(m.fields); i++ {
-		f := v.Field(i)
-		if isIntIn(i, m.primaryFieldIndecies) && f.IsZero() {
-			continue
+// getScanFieldIndecies returns the indecies of the fields the selected
+// columns are scanned into.
+func (m *model) getScanFieldIndecies(columns []string) ([]int, error) {
+	indecies := make([]int, len(columns))
+	for j, column := range columns {
+		indecies[j] = m.getFieldIndexByName(column)
+		if indecies[j] < 0 {
+			return nil, fmt.Errorf("no field for column %s", column)
 		}
-		args = append(args, f.Interface())
+	}
+	return indecies, nil
+}
+
+// getArgs returns the values of the fields that are inserted for v.
+func (m *model) getArgs(v reflect.Value) []interface{} {
+	fieldIndecies := m.getCachedInsert(v).fieldIndecies
+	args := make([]interface{}, len(fieldIndecies))
+	for j, i := range fieldIndecies {
+		args[j] = m.fieldValue(v, i).Interface()
+	}
+	return args
+}
+
+// getUpdateArgs returns the values of the fields at the given indecies
+// followed by the values of the primary fields.
+func (m *model) getUpdateArgs(v reflect.Value, fieldIndecies []int) []interface{} {
+	args := make([]interface{}, 0, len(fieldIndecies)+len(m.primaryFieldIndecies))
+	for _, i := range fieldIndecies {
+		args = append(args, m.fieldValue(v, i).Interface())
+	}
+	args = append(args, m.getPrimaryArgs(v)...)
+	if m.versionFieldIndex >= 0 {
+		args = append(args, m.fieldValue(v, m.versionFieldIndex).Interface())
 	}
 	return args
 }
 
-func (m *model) getArgsPrimaryLast(v reflect.Value) []interface{} {
+func (m *model) getPrimaryArgs(v reflect.Value) []interface{} {
 	var args []interface{}
-	var primaryArgs []interface{}
-	i := 0
-	for {
-		if i == len(m.fields) {
-			break
-		}
-		arg := v.Field(i).Interface()
-		if isIntIn(i, m.primaryFieldIndecies) {
-			primaryArgs = append(primaryArgs, arg)
-		} else {
-			args = append(args, arg)
-		}
-		i++
+	for _, i := range m.primaryFieldIndecies {
+		args = append(args, m.fieldValue(v, i).Interface())
 	}
-	args = append(args, primaryArgs...)
 	return args
 }
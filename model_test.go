@@ -0,0 +1,279 @@
+package gosql_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/twharmon/gosql"
+)
+
+type skippedFirst struct {
+	Skipped string `col:"-"`
+	ID      int    `idx:"primary"`
+	Name    string
+	Email   string
+}
+
+type skippedMiddle struct {
+	ID      int `idx:"primary"`
+	Name    string
+	Skipped string `col:"-"`
+	Email   string
+}
+
+type unexportedMiddle struct {
+	ID         int `idx:"primary"`
+	Name       string
+	unexported string
+	Email      string
+}
+
+type embeddedScanner struct {
+	ID int `idx:"primary"`
+	gosql.NullString
+	Name  string
+	Email string
+}
+
+func TestFieldMappingInsert(t *testing.T) {
+	for _, obj := range []interface{}{
+		&skippedFirst{Skipped: "x", Name: "foo", Email: "foo@example.com"},
+		&skippedMiddle{Name: "foo", Skipped: "x", Email: "foo@example.com"},
+		&unexportedMiddle{Name: "foo", unexported: "x", Email: "foo@example.com"},
+	} {
+		db, mock, err := getMockDB()
+		check(t, err)
+		mock.ExpectExec(`^insert into \w+ \(name, email\) values \(\?, \?\)$`).WithArgs("foo", "foo@example.com").WillReturnResult(sqlmock.NewResult(0, 1))
+		_, err = db.Insert(obj)
+		check(t, err)
+		check(t, mock.ExpectationsWereMet())
+	}
+}
+
+func TestFieldMappingUpdate(t *testing.T) {
+	for _, obj := range []interface{}{
+		&skippedFirst{Skipped: "x", ID: 5, Name: "foo", Email: "foo@example.com"},
+		&skippedMiddle{ID: 5, Name: "foo", Skipped: "x", Email: "foo@example.com"},
+		&unexportedMiddle{ID: 5, Name: "foo", unexported: "x", Email: "foo@example.com"},
+	} {
+		db, mock, err := getMockDB()
+		check(t, err)
+		mock.ExpectExec(`^update \w+ set name = \?, email = \? where id = \?$`).WithArgs("foo", "foo@example.com", 5).WillReturnResult(sqlmock.NewResult(0, 1))
+		_, err = db.Update(obj)
+		check(t, err)
+		check(t, mock.ExpectationsWereMet())
+	}
+}
+
+func TestFieldMappingSelectOne(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	rows := sqlmock.NewRows([]string{"id", "name", "email"})
+	rows.AddRow(5, "foo", "foo@example.com")
+	mock.ExpectQuery(`^select \* from skipped_middle limit 1$`).WillReturnRows(rows)
+	var test skippedMiddle
+	check(t, db.Select("*").Get(&test))
+	check(t, mock.ExpectationsWereMet())
+	equals(t, skippedMiddle{ID: 5, Name: "foo", Email: "foo@example.com"}, test)
+}
+
+func TestFieldMappingSelectMany(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	rows := sqlmock.NewRows([]string{"id", "name", "email"})
+	rows.AddRow(5, "foo", "foo@example.com")
+	mock.ExpectQuery(`^select \* from unexported_middle$`).WillReturnRows(rows)
+	var test []*unexportedMiddle
+	check(t, db.Select("*").Get(&test))
+	check(t, mock.ExpectationsWereMet())
+	equals(t, unexportedMiddle{ID: 5, Name: "foo", Email: "foo@example.com"}, *test[0])
+}
+
+func TestFieldMappingSelectManyValues(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	rows := sqlmock.NewRows([]string{"id", "name", "email"})
+	rows.AddRow(5, "foo", "foo@example.com")
+	mock.ExpectQuery(`^select \* from skipped_first$`).WillReturnRows(rows)
+	var test []skippedFirst
+	check(t, db.Select("*").Get(&test))
+	check(t, mock.ExpectationsWereMet())
+	equals(t, skippedFirst{ID: 5, Name: "foo", Email: "foo@example.com"}, test[0])
+}
+
+func TestFieldMappingEmbeddedScanner(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	obj := embeddedScanner{
+		ID:         5,
+		NullString: gosql.NullString{String: "bar", Valid: true},
+		Name:       "foo",
+		Email:      "foo@example.com",
+	}
+	mock.ExpectExec(`^update embedded_scanner set null_string = \?, name = \?, email = \? where id = \?$`).WithArgs(obj.NullString, obj.Name, obj.Email, obj.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.Update(&obj)
+	check(t, err)
+	rows := sqlmock.NewRows([]string{"id", "null_string", "name", "email"})
+	rows.AddRow(5, "bar", "foo", "foo@example.com")
+	mock.ExpectQuery(`^select \* from embedded_scanner limit 1$`).WillReturnRows(rows)
+	var test embeddedScanner
+	check(t, db.Select("*").Get(&test))
+	check(t, mock.ExpectationsWereMet())
+	equals(t, obj, test)
+}
+
+type Base struct {
+	ID        int `idx:"primary"`
+	CreatedAt time.Time
+}
+
+type embeddedBase struct {
+	Base
+	Name string
+}
+
+type embeddedBasePtr struct {
+	*Base
+	Name string
+}
+
+func TestEmbeddedInsert(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	now := time.Now()
+	obj := embeddedBase{Base: Base{CreatedAt: now}, Name: "foo"}
+	mock.ExpectExec(`^insert into embedded_base \(created_at, name\) values \(\?, \?\)$`).WithArgs(now, "foo").WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.Insert(&obj)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestEmbeddedUpdate(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	now := time.Now()
+	obj := embeddedBasePtr{Base: &Base{ID: 5, CreatedAt: now}, Name: "foo"}
+	mock.ExpectExec(`^update embedded_base_ptr set created_at = \?, name = \? where id = \?$`).WithArgs(now, "foo", 5).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.Update(&obj)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestEmbeddedNilPtrDelete(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectExec(`^delete from embedded_base_ptr where id = \?$`).WithArgs(0).WillReturnResult(sqlmock.NewResult(0, 0))
+	_, err = db.Delete(&embeddedBasePtr{Name: "foo"})
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestEmbeddedSelectOne(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "created_at", "name"})
+	rows.AddRow(5, now, "foo")
+	mock.ExpectQuery(`^select \* from embedded_base_ptr limit 1$`).WillReturnRows(rows)
+	var test embeddedBasePtr
+	check(t, db.Select("*").Get(&test))
+	check(t, mock.ExpectationsWereMet())
+	equals(t, Base{ID: 5, CreatedAt: now}, *test.Base)
+	equals(t, "foo", test.Name)
+}
+
+func TestEmbeddedSelectMany(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "created_at", "name"})
+	rows.AddRow(5, now, "foo")
+	rows.AddRow(6, now, "bar")
+	mock.ExpectQuery(`^select \* from embedded_base_ptr$`).WillReturnRows(rows)
+	var test []*embeddedBasePtr
+	check(t, db.Select("*").Get(&test))
+	check(t, mock.ExpectationsWereMet())
+	equals(t, 2, len(test))
+	equals(t, Base{ID: 6, CreatedAt: now}, *test[1].Base)
+	equals(t, "bar", test[1].Name)
+}
+
+func TestEmbeddedSelectManyValues(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "created_at", "name"})
+	rows.AddRow(5, now, "foo")
+	mock.ExpectQuery(`^select \* from embedded_base$`).WillReturnRows(rows)
+	var test []embeddedBase
+	check(t, db.Select("*").Get(&test))
+	check(t, mock.ExpectationsWereMet())
+	equals(t, embeddedBase{Base: Base{ID: 5, CreatedAt: now}, Name: "foo"}, test[0])
+}
+
+func TestEmbeddedDuplicateColumn(t *testing.T) {
+	db, _, err := getMockDB()
+	check(t, err)
+	type T struct {
+		Base
+		ID int
+	}
+	if err := db.Register(&T{}); err == nil {
+		t.Fatalf("expected err")
+	}
+}
+
+type embeddedNested struct {
+	Flag bool
+	embeddedBasePtr
+	Email string
+}
+
+func TestEmbeddedNestedSelectQualified(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"embedded_nested.id", "created_at", "name", "flag", "e.email"})
+	rows.AddRow(5, now, "foo", true, "foo@example.com")
+	rows.AddRow(6, now, "bar", false, "bar@example.com")
+	mock.ExpectQuery(`^select \* from embedded_nested$`).WillReturnRows(rows)
+	var test []embeddedNested
+	check(t, db.Select("*").Get(&test))
+	check(t, mock.ExpectationsWereMet())
+	equals(t, 2, len(test))
+	equals(t, Base{ID: 6, CreatedAt: now}, *test[1].Base)
+	equals(t, "bar", test[1].Name)
+	equals(t, true, test[0].Flag)
+	equals(t, "bar@example.com", test[1].Email)
+}
+
+func TestSameNamedModels(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	{
+		type T struct {
+			ID   int `idx:"primary"`
+			Name string
+		}
+		mock.ExpectQuery(`^select \* from t limit 1$`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "foo"))
+		var test T
+		check(t, db.Select("*").Get(&test))
+		equals(t, "foo", test.Name)
+	}
+	{
+		// a model with the same name and another layout is a model of
+		// its own
+		type T struct {
+			ID int8 `idx:"primary"`
+		}
+		mock.ExpectQuery(`^select \* from t limit 1$`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "foo"))
+		var test T
+		if err := db.Select("*").Get(&test); err == nil {
+			t.Fatalf("expected err")
+		} else {
+			contains(t, err.Error(), "name")
+		}
+	}
+	check(t, mock.ExpectationsWereMet())
+}
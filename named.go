@@ -0,0 +1,175 @@
+package gosql
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// namedArg returns the only argument of args if it can bind named
+// parameters, which a map[string]interface{} or a struct does.
+func namedArg(args []interface{}) (interface{}, bool) {
+	if len(args) != 1 {
+		return nil, false
+	}
+	switch arg := args[0].(type) {
+	case map[string]interface{}:
+		return arg, true
+	case driver.Valuer:
+		return nil, false
+	}
+	t := reflect.TypeOf(args[0])
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct || t == timeType || reflect.PtrTo(t).Implements(valuerType) {
+		return nil, false
+	}
+	return args[0], true
+}
+
+// bindNamed rewrites the :name and @name parameters in query to ?
+// placeholders if args binds named parameters. A struct argument of a
+// query without named parameters is passed on as an ordinary argument.
+// It returns an error naming the parameters that are missing and, for a
+// map, the values that are unused.
+func bindNamed(query string, args []interface{}) (string, []interface{}, error) {
+	arg, ok := namedArg(args)
+	if !ok {
+		return query, args, nil
+	}
+	q, names := parseNamed(query)
+	values, isMap := arg.(map[string]interface{})
+	if !isMap {
+		if len(names) == 0 {
+			return query, args, nil
+		}
+		var err error
+		if values, err = structParams(arg); err != nil {
+			return "", nil, err
+		}
+	}
+	bound := make([]interface{}, len(names))
+	var missing []string
+	used := make(map[string]bool)
+	for i, name := range names {
+		value, ok := values[name]
+		if !ok && !used[name] {
+			missing = append(missing, name)
+		}
+		used[name] = true
+		bound[i] = value
+	}
+	if len(missing) > 0 {
+		return "", nil, fmt.Errorf("missing named parameters %s", strings.Join(missing, ", "))
+	}
+	if isMap {
+		var unused []string
+		for name := range values {
+			if !used[name] {
+				unused = append(unused, name)
+			}
+		}
+		if len(unused) > 0 {
+			sort.Strings(unused)
+			return "", nil, fmt.Errorf("unused named parameters %s", strings.Join(unused, ", "))
+		}
+	}
+	return q, bound, nil
+}
+
+// parseNamed returns query with its :name and @name parameters
+// rewritten to ? placeholders, and the names of the parameters in the
+// order they appear. Parameters in quoted strings and identifiers are
+// left alone.
+func parseNamed(query string) (string, []string) {
+	var q strings.Builder
+	var names []string
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == ':' || c == '@':
+			if i+1 < len(query) && query[i+1] == c {
+				// a :: cast or an @@ variable
+				q.WriteString(query[i : i+2])
+				i++
+				continue
+			}
+			end := i + 1
+			for end < len(query) && isNameByte(query[end], end == i+1) {
+				end++
+			}
+			if end == i+1 {
+				break
+			}
+			names = append(names, query[i+1:end])
+			q.WriteByte('?')
+			i = end - 1
+			continue
+		}
+		q.WriteByte(c)
+	}
+	return q.String(), names
+}
+
+func isNameByte(c byte, first bool) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || !first && c >= '0' && c <= '9'
+}
+
+// structParams returns the values of the exported fields of the struct
+// obj by their column names. Fields of embedded structs are added as if
+// they were fields of obj. Unlike the fields of a model, the fields may
+// be of any type, since only those named in the query are bound.
+func structParams(obj interface{}) (map[string]interface{}, error) {
+	v := reflect.Indirect(reflect.ValueOf(obj))
+	if !v.IsValid() {
+		return nil, fmt.Errorf("named parameters must not be a nil pointer")
+	}
+	values := make(map[string]interface{})
+	addStructParams(values, v)
+	return values, nil
+}
+
+// addStructParams adds the fields of the struct v to values. A column
+// that is already in values is left as it is, like for models. Fields
+// of nil embedded pointers are not added.
+func addStructParams(values map[string]interface{}, v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		if f.Tag.Get("col") == "-" {
+			continue
+		}
+		if f.Anonymous && !isSupportedType(f.Type) {
+			e := v.Field(i)
+			if e.Kind() == reflect.Ptr {
+				if e.IsNil() {
+					continue
+				}
+				e = e.Elem()
+			}
+			if e.Kind() == reflect.Struct {
+				addStructParams(values, e)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		column := toSnakeCase(f.Name)
+		if tag, ok := f.Tag.Lookup("col"); ok {
+			column = tag
+		}
+		if _, ok := values[column]; !ok {
+			values[column] = v.Field(i).Interface()
+		}
+	}
+}
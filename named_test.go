@@ -0,0 +1,185 @@
+package gosql_test
+
+import (
+	"database/sql/driver"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/twharmon/gosql"
+)
+
+func TestNamedSelectMap(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithDialect(gosql.PostgreSQL))
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	mock.ExpectQuery(`^select \* from t where id > \$1 and \(name = \$2 or nick = \$3\) and created::date = \$4 limit 1$`).WithArgs(5, "foo", "foo", "2020-01-01").WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(6, "foo"))
+	var model T
+	check(t, db.Select("*").Where("id > :id and (name = :name or nick = @name) and created::date = :day", map[string]interface{}{"id": 5, "name": "foo", "day": "2020-01-01"}).Get(&model))
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestNamedStruct(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type Filter struct {
+		MinAge  int
+		Country string `col:"country_code"`
+	}
+	mock.ExpectQuery(`^select count\(\*\) from t join c on c\.code = t\.country and c\.code = \? where age >= \?$`).WithArgs("NZ", 18).WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(4))
+	filter := Filter{18, "NZ"}
+	count, err := db.Count("t", "*").Join("c on c.code = t.country and c.code = :country_code", filter).Where("age >= :min_age", &filter).Exec()
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	equals(t, count, int64(4))
+}
+
+func TestNamedStructUnsupportedField(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type Base struct {
+		ID int
+	}
+	type DTO struct {
+		*Base
+		A    string
+		Tags []string
+	}
+	mock.ExpectExec(`^update t set a = \? where id = \?$`).WithArgs("x", 5).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.Exec("update t set a = :a where id = :id", DTO{&Base{5}, "x", []string{}})
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+// point is a struct that is passed to the driver as a value.
+type point struct {
+	X, Y int
+}
+
+func (p *point) Value() (driver.Value, error) {
+	return strconv.Itoa(p.X) + "," + strconv.Itoa(p.Y), nil
+}
+
+// pointConverter converts points like the drivers of databases with a
+// point type.
+type pointConverter struct{}
+
+func (pointConverter) ConvertValue(v interface{}) (driver.Value, error) {
+	if p, ok := v.(point); ok {
+		return p.Value()
+	}
+	return driver.DefaultParameterConverter.ConvertValue(v)
+}
+
+func TestNamedStructWithoutParameters(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.ValueConverterOption(pointConverter{}))
+	check(t, err)
+	db := gosql.New(sqlDB)
+	mock.ExpectQuery(`^select count\(\*\) from t where location = \?$`).WithArgs("1,2").WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(1))
+	mock.ExpectExec(`^delete from t where location = \?$`).WithArgs("1,2").WillReturnResult(sqlmock.NewResult(0, 1))
+	count, err := db.Count("t", "*").Where("location = ?", point{1, 2}).Exec()
+	check(t, err)
+	equals(t, count, int64(1))
+	_, err = db.Exec("delete from t where location = ?", point{1, 2})
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestNamedUpdateSet(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithDialect(gosql.PostgreSQL))
+	check(t, err)
+	mock.ExpectExec(`^update t join u on u\.id = \$1 set name = \$2 where id in \(\$3, \$4\)$`).WithArgs(9, "foo", 1, 2).WillReturnResult(sqlmock.NewResult(0, 2))
+	_, err = db.ManualUpdate("t").Join("u on u.id = :uid", map[string]interface{}{"uid": 9}).Set("name = :name", map[string]interface{}{"name": "foo"}).Where("id in (:ids)", map[string]interface{}{"ids": []int{1, 2}}).Exec()
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestNamedMissing(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	_, err = db.ManualDelete("t").Where("id = :id and name = :name", map[string]interface{}{"id": 1}).Exec()
+	if err == nil || !strings.Contains(err.Error(), "missing named parameters name") {
+		t.Fatalf("expected missing parameter error, got %v", err)
+	}
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestNamedUnused(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID int `idx:"primary"`
+	}
+	var model T
+	err = db.Select("*").Where("id = :id", map[string]interface{}{"id": 1, "name": "foo", "age": 2}).Get(&model)
+	if err == nil || !strings.Contains(err.Error(), "unused named parameters age, name") {
+		t.Fatalf("expected unused parameter error, got %v", err)
+	}
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestNamedRawQuery(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithDialect(gosql.PostgreSQL))
+	check(t, err)
+	mock.ExpectExec(`^delete from t where id = \$1 and '@x' = \$2$`).WithArgs(1, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.Exec("delete from t where id = :id and '@x' = @id", map[string]interface{}{"id": 1})
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestNamedRawQueryError(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	_, err = db.Query("select * from t where id = :id", map[string]interface{}{})
+	if err == nil || !strings.Contains(err.Error(), "missing named parameters id") {
+		t.Fatalf("expected missing parameter error, got %v", err)
+	}
+	check(t, mock.ExpectationsWereMet())
+}
+
+// anyConverter accepts any argument, like the drivers that pass values
+// they do not know to the database.
+type anyConverter struct{}
+
+func (anyConverter) ConvertValue(v interface{}) (driver.Value, error) {
+	return v, nil
+}
+
+func TestNamedQueryRowError(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.ValueConverterOption(anyConverter{}))
+	check(t, err)
+	db := gosql.New(sqlDB)
+	mock.ExpectBegin()
+	var id int
+	err = db.QueryRow("select id from t where id = :id", map[string]interface{}{}).Scan(&id)
+	if err == nil || !strings.Contains(err.Error(), "missing named parameters id") {
+		t.Fatalf("expected missing parameter error, got %v", err)
+	}
+	tx, err := db.Begin()
+	check(t, err)
+	err = tx.QueryRow("select id from t where id = :id", map[string]interface{}{}).Scan(&id)
+	if err == nil || !strings.Contains(err.Error(), "missing named parameters id") {
+		t.Fatalf("expected missing parameter error, got %v", err)
+	}
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestNamedQueryRowErrorSQLite(t *testing.T) {
+	db := getSQLiteDB(t, "create table t (id integer not null primary key)")
+	var id int
+	err := db.QueryRow("select id from t where id = :id", map[string]interface{}{"x": 1}).Scan(&id)
+	if err == nil || !strings.Contains(err.Error(), "missing named parameters id") {
+		t.Fatalf("expected missing parameter error, got %v", err)
+	}
+}
+
+func TestNamedSQLite(t *testing.T) {
+	db := getSQLiteDB(t, "create table t (id integer not null primary key, name text); insert into t (name) values ('a'), ('b')")
+	var id int
+	check(t, db.QueryRow("select id from t where name = :name", map[string]interface{}{"name": "b"}).Scan(&id))
+	equals(t, id, 2)
+}
@@ -0,0 +1,113 @@
+package gosql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Page describes a page of results returned by SelectQuery.Page.
+type Page struct {
+	// Total is the number of rows the query selects on all pages.
+	Total int64
+
+	// Page is the number of the page, starting at 1.
+	Page int64
+
+	// PerPage is the maximum number of rows on a page.
+	PerPage int64
+
+	// Pages is the number of pages.
+	Pages int64
+}
+
+// pageTxOptions are the options of the transaction a page and its total
+// are selected in. Under repeatable read, both queries read the same
+// snapshot of the database, so the page and the total agree.
+var pageTxOptions = &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+
+// Page sets the results of the given page of the query to out, which
+// must be a pointer to a slice, and returns the page with the total
+// number of rows the query selects. Pages are numbered from 1. The rows
+// are counted with the same joins, conditions and grouping as the query
+// in a read only transaction with repeatable read isolation, so the
+// page and the total agree. On a Tx, the queries run in that
+// transaction, whose isolation level decides whether they agree. The
+// query is left as it is, so it can be run again.
+func (sq *SelectQuery) Page(page int64, perPage int64, out interface{}) (*Page, error) {
+	return sq.PageContext(context.Background(), page, perPage, out)
+}
+
+// PageContext is like Page, but uses the given context for the queries.
+func (sq *SelectQuery) PageContext(ctx context.Context, page int64, perPage int64, out interface{}) (*Page, error) {
+	if sq.err != nil {
+		return nil, sq.err
+	}
+	if page < 1 {
+		return nil, fmt.Errorf("page must be at least 1 (%d found)", page)
+	}
+	if perPage < 1 {
+		return nil, fmt.Errorf("per page must be at least 1 (%d found)", perPage)
+	}
+	t := reflect.TypeOf(out)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Slice || t.Elem().Elem().Kind() == reflect.Uint8 {
+		return nil, fmt.Errorf("out must be a pointer to a slice (%s found)", t)
+	}
+	// the querier, limit and offset are set on a copy of the query
+	q := *sq
+	sq = &q
+	tx := sq.tx
+	if tx == nil {
+		var err error
+		if tx, err = sq.db.BeginTx(ctx, pageTxOptions); err != nil {
+			return nil, err
+		}
+		defer tx.Rollback()
+		sq.querier = tx.tx
+	}
+	sq.limit = perPage
+	sq.offset = (page - 1) * perPage
+	if err := sq.GetContext(ctx, out); err != nil {
+		return nil, err
+	}
+	var total int64
+	query, args := bind(sq.db.dialect, sq.countSQL(), sq.args())
+	if err := tx.tx.QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
+		return nil, err
+	}
+	if sq.tx == nil {
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+	}
+	return &Page{
+		Total:   total,
+		Page:    page,
+		PerPage: perPage,
+		Pages:   (total + perPage - 1) / perPage,
+	}, nil
+}
+
+// countSQL returns the query counting the rows the query selects
+// without its order, limit and offset, with ? placeholders.
+func (sq *SelectQuery) countSQL() string {
+	var q strings.Builder
+	distinct := strings.HasPrefix(strings.ToLower(sq.fields[0]), "distinct ")
+	if sq.groupBy == "" && len(sq.havings) == 0 && !distinct {
+		q.WriteString("select count(*)")
+		sq.writeFrom(&q)
+		return q.String()
+	}
+	q.WriteString("select count(*) from (select ")
+	if distinct {
+		q.WriteString(strings.Join(sq.fields, ", "))
+	} else {
+		q.WriteString("1")
+	}
+	sq.writeFrom(&q)
+	q.WriteString(") ")
+	q.WriteString(sq.db.dialect.Quote("gosql_page"))
+	return q.String()
+}
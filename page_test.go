@@ -0,0 +1,142 @@
+package gosql_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/twharmon/gosql"
+)
+
+func TestSelectQueryPage(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	check(t, db.Register(T{}))
+	rows := sqlmock.NewRows([]string{"id", "name"})
+	rows.AddRow(3, "c")
+	rows.AddRow(4, "d")
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^select \* from t join u on u\.t_id = t\.id where name != \? order by id limit 2 offset 2$`).WithArgs("z").WillReturnRows(rows)
+	mock.ExpectQuery(`^select count\(\*\) from t join u on u\.t_id = t\.id where name != \?$`).WithArgs("z").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+	mock.ExpectCommit()
+	var test []T
+	page, err := db.Select("*").Join("u on u.t_id = t.id").Where("name != ?", "z").OrderBy("id").Page(2, 2, &test)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	equals(t, 2, len(test))
+	equals(t, 3, test[0].ID)
+	equals(t, int64(5), page.Total)
+	equals(t, int64(2), page.Page)
+	equals(t, int64(2), page.PerPage)
+	equals(t, int64(3), page.Pages)
+}
+
+func TestSelectQueryPageReuse(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID int `idx:"primary"`
+	}
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^select \* from t order by id limit 2 offset 2$`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectQuery(`^select count\(\*\) from t$`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`^select \* from t order by id$`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3))
+	q := db.Select("*").OrderBy("id")
+	var test []T
+	_, err = q.Page(2, 2, &test)
+	check(t, err)
+	check(t, q.Get(&test))
+	check(t, mock.ExpectationsWereMet())
+	equals(t, 3, len(test))
+}
+
+func TestSelectQueryPageTxOptions(t *testing.T) {
+	d := &rowsDriver{columns: []string{"id"}, rows: [][]driver.Value{{int64(7)}}}
+	db := gosql.New(sql.OpenDB(driverConnector{d}))
+	type T struct {
+		ID int `idx:"primary"`
+	}
+	var test []T
+	page, err := db.Select("*").Page(1, 10, &test)
+	check(t, err)
+	equals(t, int64(7), page.Total)
+	equals(t, driver.IsolationLevel(sql.LevelRepeatableRead), d.txOptions.Isolation)
+	equals(t, true, d.txOptions.ReadOnly)
+}
+
+func TestSelectQueryPageGrouped(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	rows := sqlmock.NewRows([]string{"name"})
+	rows.AddRow("a")
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^select name from t group by name having count\(\*\) > \? limit 10$`).WithArgs(1).WillReturnRows(rows)
+	mock.ExpectQuery(`^select count\(\*\) from \(select 1 from t group by name having count\(\*\) > \?\) gosql_page$`).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectCommit()
+	var names []string
+	page, err := db.Select("name").From("t").GroupBy("name").Having("count(*) > ?", 1).Page(1, 10, &names)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	equals(t, int64(1), page.Total)
+	equals(t, int64(1), page.Pages)
+}
+
+func TestSelectQueryPageTx(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID int `idx:"primary"`
+	}
+	check(t, db.Register(T{}))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^select \* from t limit 10$`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`^select count\(\*\) from t$`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	tx, err := db.Begin()
+	check(t, err)
+	var test []*T
+	page, err := tx.Select("*").Page(1, 10, &test)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	equals(t, 0, len(test))
+	equals(t, int64(0), page.Pages)
+}
+
+func TestSelectQueryPageInvalid(t *testing.T) {
+	db, _, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID int `idx:"primary"`
+	}
+	var test []T
+	_, err = db.Select("*").Page(0, 10, &test)
+	contains(t, err.Error(), "page")
+	var one T
+	_, err = db.Select("*").Page(1, 10, &one)
+	contains(t, err.Error(), "slice")
+}
+
+func TestSelectQueryPageSQLite(t *testing.T) {
+	db := getSQLiteDB(t, "create table t (id integer not null primary key, name text not null)")
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	check(t, db.Register(T{}))
+	for i := 1; i <= 7; i++ {
+		_, err := db.Insert(&T{ID: i, Name: "foo"})
+		check(t, err)
+	}
+	var test []T
+	page, err := db.Select("*").Where("id > ?", 1).OrderBy("id").Page(2, 4, &test)
+	check(t, err)
+	equals(t, 2, len(test))
+	equals(t, 6, test[0].ID)
+	equals(t, int64(6), page.Total)
+	equals(t, int64(2), page.Pages)
+}
@@ -0,0 +1,306 @@
+package gosql
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cursors holds the cursors of the pages next to a page returned by
+// Paginate. A cursor is empty if there is no page in its direction.
+type Cursors struct {
+	Next string
+	Prev string
+}
+
+// orderKey is a column of the order of a paginated query.
+type orderKey struct {
+	column     string
+	desc       bool
+	fieldIndex int
+}
+
+// cursor is the decoded form of a pagination cursor. Order is the order
+// of the query the cursor was taken from. Values holds the order key
+// values of the row the page starts after, or before if Prev is set, as
+// pairs of a type and a string.
+type cursor struct {
+	Prev   bool        `json:"p,omitempty"`
+	Order  string      `json:"o"`
+	Values [][2]string `json:"v"`
+}
+
+// Paginate sets a page of at most pageSize results of the query to out,
+// which must be a slice of structs or a slice of pointers to structs.
+// The page starts after the row the cursor was taken from, or at the
+// first row if cursor is empty. Instead of an offset, rows are skipped
+// by a condition on the columns of OrderBy, to which the primary
+// columns of the model are added if they are not part of it. The
+// values of these columns must not be null. The returned cursors are
+// passed to Paginate to get the next and previous pages, with a query
+// of the same order. The query is left as it is, so it can be run again.
+func (sq *SelectQuery) Paginate(pageSize int64, cursor string, out interface{}) (*Cursors, error) {
+	return sq.PaginateContext(context.Background(), pageSize, cursor, out)
+}
+
+// PaginateContext is like Paginate, but uses the given context for the
+// query.
+func (sq *SelectQuery) PaginateContext(ctx context.Context, pageSize int64, cursor string, out interface{}) (*Cursors, error) {
+	if sq.err != nil {
+		return nil, sq.err
+	}
+	if pageSize <= 0 {
+		return nil, fmt.Errorf("page size must be positive (%d found)", pageSize)
+	}
+	t := reflect.TypeOf(out)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Slice {
+		return nil, fmt.Errorf("out must be a pointer to a slice of structs or pointers to structs")
+	}
+	el := t.Elem().Elem()
+	if el.Kind() == reflect.Ptr {
+		el = el.Elem()
+	}
+	if !isStructTarget(el) {
+		return nil, fmt.Errorf("out must be a pointer to a slice of structs or pointers to structs")
+	}
+	// the order, seek and limit are set on a copy of the query
+	q := *sq
+	sq = &q
+	if err := sq.setModel(el); err != nil {
+		return nil, err
+	}
+	keys, err := sq.getOrderKeys()
+	if err != nil {
+		return nil, err
+	}
+	prev := false
+	if cursor != "" {
+		c, err := decodeCursor(cursor, keys)
+		if err != nil {
+			return nil, err
+		}
+		prev = c.Prev
+		sq.seek, sq.seekArgs = getSeekCondition(keys, c.values(), prev)
+	}
+	sq.order = getKeysetOrder(keys, prev)
+	sq.limit = pageSize + 1
+	if err := sq.GetContext(ctx, out); err != nil {
+		return nil, err
+	}
+
+	v := reflect.ValueOf(out).Elem()
+	more := int64(v.Len()) > pageSize
+	if more {
+		v.SetLen(int(pageSize))
+	}
+	if prev {
+		swap := reflect.Swapper(v.Interface())
+		for i, j := 0, v.Len()-1; i < j; i, j = i+1, j-1 {
+			swap(i, j)
+		}
+	}
+	cursors := new(Cursors)
+	if v.Len() == 0 {
+		return cursors, nil
+	}
+	// a backward page always has rows after it, and a forward page
+	// has rows before it if it was not the first
+	if prev || more {
+		if cursors.Next, err = sq.encodeCursor(keys, reflect.Indirect(v.Index(v.Len()-1)), false); err != nil {
+			return nil, err
+		}
+	}
+	if prev && more || !prev && cursor != "" {
+		if cursors.Prev, err = sq.encodeCursor(keys, reflect.Indirect(v.Index(0)), true); err != nil {
+			return nil, err
+		}
+	}
+	return cursors, nil
+}
+
+// getOrderKeys returns the columns of the order of the query, followed
+// by the primary columns of the model that are not part of it.
+func (sq *SelectQuery) getOrderKeys() ([]*orderKey, error) {
+	var keys []*orderKey
+	if sq.order != "" {
+		for _, part := range strings.Split(sq.order, ",") {
+			words := strings.Fields(part)
+			if len(words) == 0 || len(words) > 2 {
+				return nil, fmt.Errorf("can not paginate by order %s", strings.TrimSpace(part))
+			}
+			key := &orderKey{column: words[0]}
+			if len(words) == 2 {
+				switch strings.ToLower(words[1]) {
+				case "asc":
+				case "desc":
+					key.desc = true
+				default:
+					return nil, fmt.Errorf("can not paginate by order %s", strings.TrimSpace(part))
+				}
+			}
+			key.fieldIndex = sq.model.getFieldIndexByName(key.column)
+			if key.fieldIndex < 0 {
+				return nil, fmt.Errorf("no field for order column %s", key.column)
+			}
+			keys = append(keys, key)
+		}
+	}
+	qualifier := ""
+	if sq.table == "" {
+		qualifier = sq.db.dialect.Quote(sq.model.table) + "."
+	}
+	for _, i := range sq.model.primaryFieldIndecies {
+		found := false
+		for _, key := range keys {
+			if key.fieldIndex == i {
+				found = true
+				break
+			}
+		}
+		if !found {
+			keys = append(keys, &orderKey{
+				column:     qualifier + sq.db.dialect.Quote(sq.model.fields[i].column),
+				fieldIndex: i,
+			})
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("query must be ordered to paginate")
+	}
+	return keys, nil
+}
+
+// getKeysetOrder returns the order by keys, which is reversed when
+// paginating backwards.
+func getKeysetOrder(keys []*orderKey, prev bool) string {
+	var order strings.Builder
+	for i, key := range keys {
+		if i > 0 {
+			order.WriteString(", ")
+		}
+		order.WriteString(key.column)
+		if key.desc != prev {
+			order.WriteString(" desc")
+		} else {
+			order.WriteString(" asc")
+		}
+	}
+	return order.String()
+}
+
+// getSeekCondition returns the condition matching the rows after values
+// in the order of keys, or before values if prev is set. For keys a asc
+// and b desc it is (a > ? or a = ? and b < ?).
+func getSeekCondition(keys []*orderKey, values []interface{}, prev bool) (string, []interface{}) {
+	var q strings.Builder
+	var args []interface{}
+	q.WriteString("(")
+	for i, key := range keys {
+		if i > 0 {
+			q.WriteString(" or ")
+		}
+		for j := 0; j < i; j++ {
+			q.WriteString(keys[j].column)
+			q.WriteString(" = ? and ")
+			args = append(args, values[j])
+		}
+		q.WriteString(key.column)
+		if key.desc != prev {
+			q.WriteString(" < ?")
+		} else {
+			q.WriteString(" > ?")
+		}
+		args = append(args, values[i])
+	}
+	q.WriteString(")")
+	return q.String(), args
+}
+
+// encodeCursor returns the cursor of the row v for the given keys.
+func (sq *SelectQuery) encodeCursor(keys []*orderKey, v reflect.Value, prev bool) (string, error) {
+	c := cursor{
+		Prev:   prev,
+		Order:  getKeysetOrder(keys, false),
+		Values: make([][2]string, len(keys)),
+	}
+	for i, key := range keys {
+		value, err := driver.DefaultParameterConverter.ConvertValue(sq.model.fieldValue(v, key.fieldIndex).Interface())
+		if err != nil {
+			return "", err
+		}
+		switch value := value.(type) {
+		case int64:
+			c.Values[i] = [2]string{"i", strconv.FormatInt(value, 10)}
+		case float64:
+			c.Values[i] = [2]string{"f", strconv.FormatFloat(value, 'g', -1, 64)}
+		case bool:
+			c.Values[i] = [2]string{"b", strconv.FormatBool(value)}
+		case []byte:
+			c.Values[i] = [2]string{"y", base64.StdEncoding.EncodeToString(value)}
+		case string:
+			c.Values[i] = [2]string{"s", value}
+		case time.Time:
+			c.Values[i] = [2]string{"t", value.Format(time.RFC3339Nano)}
+		default:
+			return "", fmt.Errorf("order column %s must not be null to paginate", key.column)
+		}
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decodeCursor decodes a cursor, which must have been taken from a
+// query ordered by keys.
+func decodeCursor(s string, keys []*orderKey) (*cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	c := new(cursor)
+	if err := json.Unmarshal(b, c); err != nil || c.Order != getKeysetOrder(keys, false) || len(c.Values) != len(keys) {
+		return nil, ErrInvalidCursor
+	}
+	for _, value := range c.Values {
+		if _, err := parseCursorValue(value); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+	return c, nil
+}
+
+// values returns the order key values of the cursor, which must have
+// been validated by decodeCursor.
+func (c *cursor) values() []interface{} {
+	values := make([]interface{}, len(c.Values))
+	for i, value := range c.Values {
+		values[i], _ = parseCursorValue(value)
+	}
+	return values
+}
+
+func parseCursorValue(value [2]string) (interface{}, error) {
+	switch value[0] {
+	case "i":
+		return strconv.ParseInt(value[1], 10, 64)
+	case "f":
+		return strconv.ParseFloat(value[1], 64)
+	case "b":
+		return strconv.ParseBool(value[1])
+	case "y":
+		return base64.StdEncoding.DecodeString(value[1])
+	case "s":
+		return value[1], nil
+	case "t":
+		return time.Parse(time.RFC3339Nano, value[1])
+	}
+	return nil, ErrInvalidCursor
+}
@@ -0,0 +1,184 @@
+package gosql_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/twharmon/gosql"
+)
+
+func TestSelectQueryPaginateFirstPage(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	check(t, db.Register(T{}))
+	rows := sqlmock.NewRows([]string{"id", "name"})
+	rows.AddRow(1, "a")
+	rows.AddRow(2, "b")
+	rows.AddRow(3, "c")
+	mock.ExpectQuery(`^select \* from t where name != \? order by name desc, t\.id asc limit 3$`).WithArgs("z").WillReturnRows(rows)
+	var test []T
+	cursors, err := db.Select("*").Where("name != ?", "z").OrderBy("name desc").Paginate(2, "", &test)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	equals(t, 2, len(test))
+	equals(t, 2, test[1].ID)
+	equals(t, "", cursors.Prev)
+	if cursors.Next == "" {
+		t.Fatal("expected a next cursor")
+	}
+}
+
+func TestSelectQueryPaginateNext(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	check(t, db.Register(T{}))
+	rows := sqlmock.NewRows([]string{"id", "name"})
+	rows.AddRow(1, "a")
+	rows.AddRow(2, "b")
+	rows.AddRow(3, "c")
+	mock.ExpectQuery(`^select \* from t order by name desc, t\.id asc limit 3$`).WillReturnRows(rows)
+	var test []T
+	cursors, err := db.Select("*").OrderBy("name desc").Paginate(2, "", &test)
+	check(t, err)
+	rows = sqlmock.NewRows([]string{"id", "name"})
+	rows.AddRow(3, "c")
+	mock.ExpectQuery(`^select \* from t where \(name != \? or name = \?\) and \(name < \? or name = \? and t\.id > \?\) order by name desc, t\.id asc limit 3$`).
+		WithArgs("z", "y", "b", "b", int64(2)).
+		WillReturnRows(rows)
+	cursors, err = db.Select("*").Where("name != ?", "z").OrWhere("name = ?", "y").OrderBy("name desc").Paginate(2, cursors.Next, &test)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	equals(t, 1, len(test))
+	equals(t, "", cursors.Next)
+	if cursors.Prev == "" {
+		t.Fatal("expected a prev cursor")
+	}
+}
+
+func TestSelectQueryPaginateInvalidCursor(t *testing.T) {
+	db, _, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID int `idx:"primary"`
+	}
+	check(t, db.Register(T{}))
+	var test []T
+	if _, err := db.Select("*").Paginate(2, "foo", &test); err != gosql.ErrInvalidCursor {
+		t.Fatalf("expected %v, got %v", gosql.ErrInvalidCursor, err)
+	}
+}
+
+func TestSelectQueryPaginateUnknownOrder(t *testing.T) {
+	db, _, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID int `idx:"primary"`
+	}
+	check(t, db.Register(T{}))
+	var test []T
+	_, err = db.Select("*").OrderBy("lower(name)").Paginate(2, "", &test)
+	contains(t, err.Error(), "lower(name)")
+}
+
+func TestSelectQueryPaginateSQLite(t *testing.T) {
+	db := getSQLiteDB(t, "create table t (id integer not null primary key, score integer not null, name text not null)")
+	type T struct {
+		ID    int `idx:"primary"`
+		Score int
+		Name  string
+	}
+	check(t, db.Register(T{}))
+	scores := []int{3, 1, 3, 2, 1, 3, 2}
+	for i, score := range scores {
+		_, err := db.Insert(&T{ID: i + 1, Score: score, Name: "foo"})
+		check(t, err)
+	}
+	// score desc, id asc: 1 3 6 4 7 2 5
+	expected := []int{1, 3, 6, 4, 7, 2, 5}
+	var ids []int
+	cursor := ""
+	for {
+		var page []*T
+		cursors, err := db.Select("*").OrderBy("score desc").Paginate(3, cursor, &page)
+		check(t, err)
+		for _, test := range page {
+			ids = append(ids, test.ID)
+		}
+		if cursors.Next == "" {
+			break
+		}
+		cursor = cursors.Next
+	}
+	equals(t, len(expected), len(ids))
+	for i := range expected {
+		equals(t, expected[i], ids[i])
+	}
+
+	var page, prevPage []T
+	cursors, err := db.Select("*").OrderBy("score desc").Paginate(3, "", &prevPage)
+	check(t, err)
+	cursors, err = db.Select("*").OrderBy("score desc").Paginate(3, cursors.Next, &page)
+	check(t, err)
+	cursors, err = db.Select("*").OrderBy("score desc").Paginate(3, cursors.Prev, &prevPage)
+	check(t, err)
+	equals(t, 3, len(prevPage))
+	equals(t, 1, prevPage[0].ID)
+	equals(t, 3, prevPage[1].ID)
+	equals(t, 6, prevPage[2].ID)
+	equals(t, "", cursors.Prev)
+	if cursors.Next == "" {
+		t.Fatal("expected a next cursor")
+	}
+}
+
+func TestSelectQueryPaginateReuse(t *testing.T) {
+	db := getSQLiteDB(t, "create table t (id integer not null primary key)")
+	type T struct {
+		ID int `idx:"primary"`
+	}
+	for i := 1; i <= 7; i++ {
+		_, err := db.Insert(&T{ID: i})
+		check(t, err)
+	}
+	q := db.Select("*").OrderBy("id desc")
+	var page []T
+	cursors, err := q.Paginate(3, "", &page)
+	check(t, err)
+	_, err = q.Paginate(3, cursors.Next, &page)
+	check(t, err)
+	equals(t, 4, page[0].ID)
+	_, err = q.Paginate(3, "", &page)
+	check(t, err)
+	equals(t, 3, len(page))
+	equals(t, 7, page[0].ID)
+	var all []T
+	check(t, q.Get(&all))
+	equals(t, 7, len(all))
+	equals(t, 7, all[0].ID)
+}
+
+func TestSelectQueryPaginateOtherOrder(t *testing.T) {
+	db := getSQLiteDB(t, "create table t (id integer not null primary key, score integer not null)")
+	type T struct {
+		ID    int `idx:"primary"`
+		Score int
+	}
+	for i := 1; i <= 4; i++ {
+		_, err := db.Insert(&T{ID: i, Score: i})
+		check(t, err)
+	}
+	var page []T
+	cursors, err := db.Select("*").OrderBy("score").Paginate(2, "", &page)
+	check(t, err)
+	if _, err := db.Select("*").OrderBy("score desc").Paginate(2, cursors.Next, &page); err != gosql.ErrInvalidCursor {
+		t.Fatalf("expected %v, got %v", gosql.ErrInvalidCursor, err)
+	}
+}
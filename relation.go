@@ -0,0 +1,425 @@
+package gosql
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type relationKind int
+
+const (
+	hasMany relationKind = iota
+	belongsTo
+	manyToMany
+)
+
+// relation is a field of a model tagged `rel:"..."` that holds related
+// models.
+type relation struct {
+	name string
+	kind relationKind
+
+	// index is the index sequence of the struct field.
+	index []int
+
+	// target is the struct type of the related models and ptr reports
+	// whether the field holds pointers to them.
+	target reflect.Type
+	ptr    bool
+
+	// fk is the foreign key column. It is a column of the related model
+	// for has_many, of the model itself for belongs_to, and of the join
+	// table referencing the model for many_to_many.
+	fk string
+
+	// join is the join table of a many_to_many relation and ref is its
+	// column referencing the related model.
+	join string
+	ref  string
+}
+
+// addRelation adds the struct field f tagged `rel:"..."` to the
+// relations of m.
+func (m *model) addRelation(f reflect.StructField, index []int, tag string) error {
+	if !f.IsExported() {
+		return fmt.Errorf("model %s field %s tagged `rel` must be exported", m.name, f.Name)
+	}
+	parts := strings.Split(tag, ",")
+	r := &relation{name: f.Name, index: index}
+	t := f.Type
+	switch parts[0] {
+	case "has_many", "many_to_many":
+		if t.Kind() != reflect.Slice {
+			return fmt.Errorf("model %s field %s tagged `rel:\"%s\"` must be a slice", m.name, f.Name, parts[0])
+		}
+		t = t.Elem()
+		r.kind = hasMany
+		if parts[0] == "many_to_many" {
+			r.kind = manyToMany
+		}
+	case "belongs_to":
+		r.kind = belongsTo
+	default:
+		return fmt.Errorf("model %s field %s has unknown relation %s", m.name, f.Name, parts[0])
+	}
+	if t.Kind() == reflect.Ptr {
+		r.ptr = true
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("model %s field %s tagged `rel` must hold structs or pointers to structs", m.name, f.Name)
+	}
+	r.target = t
+	for _, option := range parts[1:] {
+		kv := strings.SplitN(option, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("model %s field %s has invalid relation option %s", m.name, f.Name, option)
+		}
+		switch kv[0] {
+		case "fk":
+			r.fk = kv[1]
+		case "join":
+			r.join = kv[1]
+		case "ref":
+			r.ref = kv[1]
+		default:
+			return fmt.Errorf("model %s field %s has unknown relation option %s", m.name, f.Name, kv[0])
+		}
+	}
+	if r.fk == "" {
+		if r.kind == belongsTo {
+			r.fk = toSnakeCase(f.Name) + "_id"
+		} else {
+			r.fk = toSnakeCase(m.name) + "_id"
+		}
+	}
+	if r.kind == manyToMany {
+		if r.join == "" {
+			return fmt.Errorf("model %s field %s tagged `rel:\"many_to_many\"` must have a join table", m.name, f.Name)
+		}
+		if r.ref == "" {
+			r.ref = toSnakeCase(t.Name()) + "_id"
+		}
+	}
+	m.relations = append(m.relations, r)
+	return nil
+}
+
+func (m *model) getRelation(name string) *relation {
+	for _, r := range m.relations {
+		if r.name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// getPrimaryField returns the index in fields of the only primary
+// field of m.
+func (m *model) getPrimaryField() (int, error) {
+	if len(m.primaryFieldIndecies) != 1 {
+		return 0, fmt.Errorf("model %s must have exactly one primary field to be related", m.name)
+	}
+	return m.primaryFieldIndecies[0], nil
+}
+
+// Preload loads the related models in the fields tagged `rel:"..."`
+// with the given names after the query is run. Related models of related
+// models are loaded with dot separated names, such as "Posts.Comments".
+// Each relation is loaded with one query.
+func (sq *SelectQuery) Preload(names ...string) *SelectQuery {
+	sq.preloads = append(sq.preloads, names...)
+	return sq
+}
+
+// preload loads the relations named by paths into values, which are
+// addressable structs of model m.
+func (sq *SelectQuery) preload(ctx context.Context, m *model, values []reflect.Value, paths []string) error {
+	var names []string
+	nested := make(map[string][]string)
+	for _, path := range paths {
+		name := path
+		rest := ""
+		if i := strings.IndexByte(path, '.'); i >= 0 {
+			name = path[:i]
+			rest = path[i+1:]
+		}
+		if _, ok := nested[name]; !ok {
+			names = append(names, name)
+			nested[name] = nil
+		}
+		if rest != "" {
+			nested[name] = append(nested[name], rest)
+		}
+	}
+	for _, name := range names {
+		r := m.getRelation(name)
+		if r == nil {
+			return fmt.Errorf("model %s has no relation %s", m.name, name)
+		}
+		target, err := sq.db.getModelOf(r.target)
+		if err != nil {
+			return err
+		}
+		if err := sq.loadRelation(ctx, m, target, r, values, nested[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadRelation loads relation r of model m into values and then the
+// nested relations of the related models.
+func (sq *SelectQuery) loadRelation(ctx context.Context, m *model, target *model, r *relation, values []reflect.Value, nested []string) error {
+	var ownerField, keyField int
+	var err error
+	switch r.kind {
+	case hasMany:
+		if ownerField, err = m.getPrimaryField(); err != nil {
+			return err
+		}
+		if keyField = target.getFieldIndexByColumn(r.fk); keyField < 0 {
+			return fmt.Errorf("model %s has no field for column %s", target.name, r.fk)
+		}
+	case belongsTo:
+		if ownerField = m.getFieldIndexByColumn(r.fk); ownerField < 0 {
+			return fmt.Errorf("model %s has no field for column %s", m.name, r.fk)
+		}
+		if keyField, err = target.getPrimaryField(); err != nil {
+			return err
+		}
+	case manyToMany:
+		if ownerField, err = m.getPrimaryField(); err != nil {
+			return err
+		}
+		if keyField, err = target.getPrimaryField(); err != nil {
+			return err
+		}
+	}
+
+	var keys []interface{}
+	seen := make(map[interface{}]bool)
+	for _, v := range values {
+		key := relationKey(m.fieldValue(v, ownerField))
+		if key == nil || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+
+	var related []reflect.Value
+	var relatedKeys []interface{}
+	var keyType reflect.Type
+	if r.kind == manyToMany {
+		// the owner key is scanned into the type of the owner field, so
+		// it matches the owner key whatever type the driver returns
+		keyType = m.fields[ownerField].typ
+	}
+	size := sq.db.dialect.MaxPlaceholders()
+	for start := 0; start < len(keys); start += size {
+		end := start + size
+		if end > len(keys) {
+			end = len(keys)
+		}
+		query := target.getRelationQuery(r, end-start)
+		rows, rowKeys, err := sq.queryRelated(ctx, target, query, keys[start:end], keyType)
+		if err != nil {
+			return err
+		}
+		related = append(related, rows...)
+		if r.kind == manyToMany {
+			relatedKeys = append(relatedKeys, rowKeys...)
+		}
+	}
+	if r.kind != manyToMany {
+		for _, v := range related {
+			relatedKeys = append(relatedKeys, relationKey(target.fieldValue(v, keyField)))
+		}
+	}
+	if len(nested) > 0 && len(related) > 0 {
+		if err := sq.preload(ctx, target, related, nested); err != nil {
+			return err
+		}
+	}
+
+	byKey := make(map[interface{}][]reflect.Value)
+	for i, v := range related {
+		byKey[relatedKeys[i]] = append(byKey[relatedKeys[i]], v)
+	}
+	for _, v := range values {
+		f := settableByIndex(v, r.index)
+		matches := byKey[relationKey(m.fieldValue(v, ownerField))]
+		if r.kind == belongsTo {
+			if len(matches) == 0 {
+				f.Set(reflect.Zero(f.Type()))
+				continue
+			}
+			f.Set(relatedValue(matches[0], r.ptr))
+			continue
+		}
+		s := reflect.MakeSlice(f.Type(), len(matches), len(matches))
+		for i, match := range matches {
+			s.Index(i).Set(relatedValue(match, r.ptr))
+		}
+		f.Set(s)
+	}
+	return nil
+}
+
+// getRelationQuery returns the query selecting the models related by r
+// to n owners. Many to many queries select the owner key last.
+func (m *model) getRelationQuery(r *relation, n int) string {
+	table := m.dialect.Quote(m.table)
+	var q strings.Builder
+	q.WriteString("select ")
+	q.WriteString(table)
+	q.WriteString(".*")
+	var column string
+	switch r.kind {
+	case hasMany:
+		q.WriteString(" from ")
+		q.WriteString(table)
+		column = table + "." + m.dialect.Quote(r.fk)
+	case belongsTo:
+		q.WriteString(" from ")
+		q.WriteString(table)
+		column = table + "." + m.dialect.Quote(m.fields[m.primaryFieldIndecies[0]].column)
+	case manyToMany:
+		join := m.dialect.Quote(r.join)
+		column = join + "." + m.dialect.Quote(r.fk)
+		q.WriteString(", ")
+		q.WriteString(column)
+		q.WriteString(" from ")
+		q.WriteString(table)
+		q.WriteString(" join ")
+		q.WriteString(join)
+		q.WriteString(" on ")
+		q.WriteString(join)
+		q.WriteString(".")
+		q.WriteString(m.dialect.Quote(r.ref))
+		q.WriteString(" = ")
+		q.WriteString(table)
+		q.WriteString(".")
+		q.WriteString(m.dialect.Quote(m.fields[m.primaryFieldIndecies[0]].column))
+	}
+	var in strings.Builder
+	in.WriteString(column)
+	in.WriteString(" in (")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			in.WriteString(", ")
+		}
+		in.WriteString("?")
+	}
+	in.WriteString(")")
+	writeWheres(&q, []*where{{condition: in.String()}}, m.getSoftDeleteCondition(excludeDeleted))
+	return rebind(m.dialect, q.String())
+}
+
+// queryRelated runs query and returns the models of the rows. If
+// keyType is not nil, the last column of each row is scanned into a
+// value of keyType and returned separately as the key of its owner.
+func (sq *SelectQuery) queryRelated(ctx context.Context, m *model, query string, args []interface{}, keyType reflect.Type) ([]reflect.Value, []interface{}, error) {
+	rows, err := sq.querier.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	columns, _ := rows.Columns()
+	fieldCount := len(columns)
+	if keyType != nil {
+		fieldCount--
+	}
+	fieldIndecies := make([]int, fieldCount)
+	for j := 0; j < fieldCount; j++ {
+		fieldIndecies[j] = m.getFieldIndexByName(columns[j])
+		if fieldIndecies[j] < 0 {
+			return nil, nil, fmt.Errorf("no field for column %s", columns[j])
+		}
+	}
+	var values []reflect.Value
+	var keys []interface{}
+	dests := make([]interface{}, len(columns))
+	for rows.Next() {
+		v := reflect.New(m.typ).Elem()
+		for j := 0; j < fieldCount; j++ {
+			dests[j] = m.fieldAddr(v, fieldIndecies[j])
+		}
+		var key reflect.Value
+		if keyType != nil {
+			key = reflect.New(keyType)
+			dests[fieldCount] = key.Interface()
+		}
+		if err := rows.Scan(dests...); err != nil {
+			return nil, nil, err
+		}
+		m.takeSnapshot(v)
+		if err := runHook(afterFind, v, sq.tx); err != nil {
+			return nil, nil, err
+		}
+		values = append(values, v)
+		if keyType != nil {
+			keys = append(keys, relationKey(key.Elem()))
+		}
+	}
+	return values, keys, rows.Err()
+}
+
+// relatedValue returns v, which is an addressable struct, as it is
+// stored in a relation field.
+func relatedValue(v reflect.Value, ptr bool) reflect.Value {
+	if ptr {
+		return v.Addr()
+	}
+	return v
+}
+
+// relationKey returns the value of a key field or column in a form that
+// can be compared with keys of other types, or nil if it is null.
+func relationKey(v reflect.Value) interface{} {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	if valuer, ok := v.Interface().(driver.Valuer); ok {
+		value, err := valuer.Value()
+		if err != nil || value == nil {
+			return nil
+		}
+		v = reflect.ValueOf(value)
+	}
+	switch {
+	case isIntKind(v.Kind()):
+		if v.CanInt() {
+			return v.Int()
+		}
+		return int64(v.Uint())
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		return string(v.Bytes())
+	}
+	return v.Interface()
+}
+
+// settableByIndex returns the struct field of v with the given index
+// sequence, allocating nil embedded pointers on the way.
+func settableByIndex(v reflect.Value, index []int) reflect.Value {
+	for j, x := range index {
+		if j > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
@@ -0,0 +1,170 @@
+package gosql_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/twharmon/gosql"
+)
+
+type relUser struct {
+	ID    int `idx:"primary"`
+	Name  string
+	Posts []*relPost `rel:"has_many,fk=user_id"`
+	Roles []relRole  `rel:"many_to_many,join=rel_user_roles,fk=user_id,ref=role_id"`
+}
+
+type relPost struct {
+	ID       int `idx:"primary"`
+	UserID   int
+	Title    string
+	User     *relUser     `rel:"belongs_to"`
+	Comments []relComment `rel:"has_many,fk=post_id"`
+}
+
+type relComment struct {
+	ID     int `idx:"primary"`
+	PostID int
+	Body   string
+}
+
+type relRole struct {
+	ID   int `idx:"primary"`
+	Name string
+}
+
+func TestPreloadHasMany(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectQuery(`^select \* from rel_user$`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "foo").AddRow(2, "bar"))
+	mock.ExpectQuery(`^select rel_post\.\* from rel_post where rel_post\.user_id in \(\?, \?\)$`).WithArgs(int64(1), int64(2)).WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "title"}).AddRow(10, 2, "a").AddRow(11, 2, "b"))
+	var users []relUser
+	check(t, db.Select("*").Preload("Posts").Get(&users))
+	check(t, mock.ExpectationsWereMet())
+	equals(t, len(users[0].Posts), 0)
+	equals(t, len(users[1].Posts), 2)
+	equals(t, users[1].Posts[1].Title, "b")
+}
+
+func TestPreloadBelongsTo(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectQuery(`^select \* from rel_post limit 1$`).WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "title"}).AddRow(10, 2, "a"))
+	mock.ExpectQuery(`^select rel_user\.\* from rel_user where rel_user\.id in \(\?\)$`).WithArgs(int64(2)).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "bar"))
+	var post relPost
+	check(t, db.Select("*").Preload("User").Get(&post))
+	check(t, mock.ExpectationsWereMet())
+	equals(t, post.User.Name, "bar")
+}
+
+func TestPreloadManyToMany(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectQuery(`^select \* from rel_user$`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "foo").AddRow(2, "bar"))
+	mock.ExpectQuery(`^select rel_role\.\*, rel_user_roles\.user_id from rel_role join rel_user_roles on rel_user_roles\.role_id = rel_role\.id where rel_user_roles\.user_id in \(\?, \?\)$`).WithArgs(int64(1), int64(2)).WillReturnRows(sqlmock.NewRows([]string{"id", "name", "user_id"}).AddRow(5, "admin", 1).AddRow(6, "editor", 1).AddRow(5, "admin", 2))
+	var users []*relUser
+	check(t, db.Select("*").Preload("Roles").Get(&users))
+	check(t, mock.ExpectationsWereMet())
+	equals(t, len(users[0].Roles), 2)
+	equals(t, len(users[1].Roles), 1)
+	equals(t, users[1].Roles[0].Name, "admin")
+}
+
+func TestPreloadManyToManyBytesKey(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectQuery(`^select \* from rel_user$`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "foo"))
+	// drivers using a text protocol return the join column as bytes
+	mock.ExpectQuery(`^select rel_role\.\*, rel_user_roles\.user_id from rel_role`).WithArgs(int64(1)).WillReturnRows(sqlmock.NewRows([]string{"id", "name", "user_id"}).AddRow(5, "admin", []byte("1")))
+	var users []relUser
+	check(t, db.Select("*").Preload("Roles").Get(&users))
+	check(t, mock.ExpectationsWereMet())
+	equals(t, len(users[0].Roles), 1)
+	equals(t, users[0].Roles[0].Name, "admin")
+}
+
+func TestPreloadNested(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectQuery(`^select \* from rel_user$`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "foo"))
+	mock.ExpectQuery(`^select rel_post\.\* from rel_post where rel_post\.user_id in \(\?\)$`).WithArgs(int64(1)).WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "title"}).AddRow(10, 1, "a").AddRow(11, 1, "b"))
+	mock.ExpectQuery(`^select rel_comment\.\* from rel_comment where rel_comment\.post_id in \(\?, \?\)$`).WithArgs(int64(10), int64(11)).WillReturnRows(sqlmock.NewRows([]string{"id", "post_id", "body"}).AddRow(20, 11, "c"))
+	var users []relUser
+	check(t, db.Select("*").Preload("Posts", "Posts.Comments").Get(&users))
+	check(t, mock.ExpectationsWereMet())
+	equals(t, len(users[0].Posts), 2)
+	equals(t, len(users[0].Posts[0].Comments), 0)
+	equals(t, users[0].Posts[1].Comments[0].Body, "c")
+}
+
+func TestPreloadNoRows(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectQuery(`^select \* from rel_user$`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	var users []relUser
+	check(t, db.Select("*").Preload("Posts.Comments").Get(&users))
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestPreloadSoftDeleted(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type SoftChild struct {
+		ID        int `idx:"primary"`
+		ParentID  int
+		DeletedAt gosql.NullTime `soft:"delete"`
+	}
+	type Parent struct {
+		ID       int         `idx:"primary"`
+		Children []SoftChild `rel:"has_many"`
+	}
+	mock.ExpectQuery(`^select \* from parent limit 1$`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`^select soft_child\.\* from soft_child where \(soft_child\.parent_id in \(\?\)\) and soft_child\.deleted_at is null$`).WithArgs(int64(1)).WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id", "deleted_at"}))
+	var parent Parent
+	check(t, db.Select("*").Preload("Children").Get(&parent))
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestPreloadUnknownRelation(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectQuery(`^select \* from rel_user limit 1$`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "foo"))
+	var user relUser
+	if err := db.Select("*").Preload("Comments").Get(&user); err == nil {
+		t.Fatal("expected error preloading unknown relation")
+	}
+}
+
+func TestRelationInvalidTag(t *testing.T) {
+	db, _, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID    int       `idx:"primary"`
+		Roles []relRole `rel:"many_to_many"`
+	}
+	if err := db.Register(T{}); err == nil {
+		t.Fatal("expected error registering many to many relation without join table")
+	}
+}
+
+func TestPreloadSQLite(t *testing.T) {
+	db := getSQLiteDB(t, `create table rel_user (id integer not null primary key, name text);
+create table rel_post (id integer not null primary key, user_id integer, title text);
+create table rel_comment (id integer not null primary key, post_id integer, body text);
+create table rel_role (id integer not null primary key, name text);
+create table rel_user_roles (user_id integer, role_id integer);
+insert into rel_user (id, name) values (1, 'foo'), (2, 'bar');
+insert into rel_post (id, user_id, title) values (10, 1, 'a'), (11, 2, 'b');
+insert into rel_comment (id, post_id, body) values (20, 10, 'c');
+insert into rel_role (id, name) values (5, 'admin');
+insert into rel_user_roles (user_id, role_id) values (2, 5);`)
+	var users []relUser
+	check(t, db.Select("*").OrderBy("id").Preload("Posts.Comments", "Roles").Get(&users))
+	equals(t, len(users), 2)
+	equals(t, users[0].Posts[0].Comments[0].Body, "c")
+	equals(t, len(users[0].Roles), 0)
+	equals(t, users[1].Roles[0].Name, "admin")
+	var posts []relPost
+	check(t, db.Select("*").OrderBy("id").Preload("User").Get(&posts))
+	equals(t, posts[1].User.Name, "bar")
+}
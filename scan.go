@@ -0,0 +1,181 @@
+package gosql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+)
+
+var mapType = reflect.TypeOf(map[string]interface{}(nil))
+
+// From sets the table to select from. It is needed when the query does
+// not select into a model, and it overrides the table of the model
+// otherwise.
+func (sq *SelectQuery) From(table string) *SelectQuery {
+	sq.table = table
+	return sq
+}
+
+// isStructTarget reports whether t is a struct whose fields are scanned
+// from the columns of a row, rather than a value scanned from a single
+// column.
+func isStructTarget(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || t == timeType {
+		return false
+	}
+	if !reflect.PtrTo(t).Implements(scannerType) {
+		return true
+	}
+	// a Scan method promoted from an embedded field does not make t a
+	// value
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && reflect.PtrTo(f.Type).Implements(scannerType) {
+			return true
+		}
+	}
+	return false
+}
+
+// getScanModel returns the model used to scan rows into the struct type
+// t when the table is set by From. The registered model is used if
+// there is one. Otherwise t is mapped without being registered, so it
+// needs no primary field.
+func (db *DB) getScanModel(t reflect.Type) (*model, error) {
+	db.modelsMu.RLock()
+	m := db.models[t]
+	db.modelsMu.RUnlock()
+	if m != nil {
+		return m, nil
+	}
+	return newModel(t, db.dialect)
+}
+
+// query runs the query with its arguments.
+func (sq *SelectQuery) query(ctx context.Context) (*sql.Rows, error) {
+	if sq.softDelete == onlyDeleted && sq.getSoftModel() == nil {
+		return nil, errNoSoftModel
+	}
+	query, args := bind(sq.db.dialect, sq.sql(), sq.args())
+	return sq.querier.QueryContext(ctx, query, args...)
+}
+
+// toValue scans the single column of the first row into out, which is
+// a pointer to a value that is not a struct target.
+func (sq *SelectQuery) toValue(ctx context.Context, out interface{}) error {
+	rows, err := sq.query(ctx)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if err := mustBeOneColumn(rows); err != nil {
+		return err
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return ErrNotFound
+	}
+	return rows.Scan(out)
+}
+
+// toValues scans the single column of each row into an element of the
+// slice outs points to.
+func (sq *SelectQuery) toValues(ctx context.Context, sliceType reflect.Type, outs interface{}) error {
+	sq.many = true
+	rows, err := sq.query(ctx)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if err := mustBeOneColumn(rows); err != nil {
+		return err
+	}
+	newOuts := reflect.MakeSlice(sliceType, 0, int(sq.limit))
+	for rows.Next() {
+		el := reflect.New(sliceType.Elem())
+		if err := rows.Scan(el.Interface()); err != nil {
+			return err
+		}
+		newOuts = reflect.Append(newOuts, el.Elem())
+	}
+	reflect.ValueOf(outs).Elem().Set(newOuts)
+	return rows.Err()
+}
+
+// toMap scans the first row into out, which is a pointer to a map of
+// columns to values.
+func (sq *SelectQuery) toMap(ctx context.Context, out *map[string]interface{}) error {
+	rows, err := sq.query(ctx)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return ErrNotFound
+	}
+	*out, err = scanMap(rows)
+	return err
+}
+
+// toMaps scans each row into a map of columns to values in the slice
+// outs points to.
+func (sq *SelectQuery) toMaps(ctx context.Context, outs *[]map[string]interface{}) error {
+	sq.many = true
+	rows, err := sq.query(ctx)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	newOuts := make([]map[string]interface{}, 0, sq.limit)
+	for rows.Next() {
+		m, err := scanMap(rows)
+		if err != nil {
+			return err
+		}
+		newOuts = append(newOuts, m)
+	}
+	*outs = newOuts
+	return rows.Err()
+}
+
+// scanMap scans the current row into a map of columns to values. Byte
+// slices are converted to strings.
+func scanMap(rows *sql.Rows) (map[string]interface{}, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(columns))
+	dests := make([]interface{}, len(columns))
+	for i := range values {
+		dests[i] = &values[i]
+	}
+	if err := rows.Scan(dests...); err != nil {
+		return nil, err
+	}
+	m := make(map[string]interface{}, len(columns))
+	for i, column := range columns {
+		if b, ok := values[i].([]byte); ok {
+			values[i] = string(b)
+		}
+		m[column] = values[i]
+	}
+	return m, nil
+}
+
+func mustBeOneColumn(rows *sql.Rows) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	if len(columns) != 1 {
+		return fmt.Errorf("query must select one column to scan into a value (%d selected)", len(columns))
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package gosql_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/twharmon/gosql"
+)
+
+func TestSelectQueryFromValues(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	rows := sqlmock.NewRows([]string{"id"})
+	rows.AddRow(1)
+	rows.AddRow(2)
+	mock.ExpectQuery(`^select id from user where age > \?$`).WithArgs(18).WillReturnRows(rows)
+	var ids []int64
+	check(t, db.Select("id").From("user").Where("age > ?", 18).Get(&ids))
+	check(t, mock.ExpectationsWereMet())
+	equals(t, 2, len(ids))
+	equals(t, int64(1), ids[0])
+	equals(t, int64(2), ids[1])
+}
+
+func TestSelectQueryFromValue(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	rows := sqlmock.NewRows([]string{"name"})
+	rows.AddRow("foo")
+	mock.ExpectQuery(`^select name from user where id = \? limit 1$`).WithArgs(5).WillReturnRows(rows)
+	var name string
+	check(t, db.Select("name").From("user").Where("id = ?", 5).Get(&name))
+	check(t, mock.ExpectationsWereMet())
+	equals(t, "foo", name)
+}
+
+func TestSelectQueryFromValueNotFound(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectQuery(`^select name from user limit 1$`).WillReturnRows(sqlmock.NewRows([]string{"name"}))
+	var name string
+	if err := db.Select("name").From("user").Get(&name); err != gosql.ErrNotFound {
+		t.Fatalf("expected %v, got %v", gosql.ErrNotFound, err)
+	}
+}
+
+func TestSelectQueryFromValueManyColumns(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	rows := sqlmock.NewRows([]string{"id", "name"})
+	rows.AddRow(1, "foo")
+	mock.ExpectQuery(`^select \* from user$`).WillReturnRows(rows)
+	var ids []int
+	err = db.Select("*").From("user").Get(&ids)
+	contains(t, err.Error(), "one column")
+}
+
+func TestSelectQueryFromMaps(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	rows := sqlmock.NewRows([]string{"id", "name"})
+	rows.AddRow(int64(1), []byte("foo"))
+	rows.AddRow(int64(2), []byte("bar"))
+	mock.ExpectQuery(`^select id, name from user$`).WillReturnRows(rows)
+	var users []map[string]interface{}
+	check(t, db.Select("id", "name").From("user").Get(&users))
+	check(t, mock.ExpectationsWereMet())
+	equals(t, 2, len(users))
+	equals(t, int64(1), users[0]["id"])
+	equals(t, "foo", users[0]["name"])
+	equals(t, int64(2), users[1]["id"])
+	equals(t, "bar", users[1]["name"])
+}
+
+func TestSelectQueryFromMap(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	rows := sqlmock.NewRows([]string{"id", "name"})
+	rows.AddRow(int64(1), "foo")
+	mock.ExpectQuery(`^select id, name from user limit 1$`).WillReturnRows(rows)
+	var user map[string]interface{}
+	check(t, db.Select("id", "name").From("user").Get(&user))
+	check(t, mock.ExpectationsWereMet())
+	equals(t, 2, len(user))
+	equals(t, int64(1), user["id"])
+	equals(t, "foo", user["name"])
+}
+
+func TestSelectQueryFromStruct(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type UserPosts struct {
+		Name  string
+		Posts int
+	}
+	rows := sqlmock.NewRows([]string{"name", "posts"})
+	rows.AddRow("foo", 3)
+	mock.ExpectQuery(`^select user\.name, count\(\*\) as posts from user join post on post\.user_id = user\.id group by user\.name$`).WillReturnRows(rows)
+	var test []UserPosts
+	check(t, db.Select("user.name", "count(*) as posts").
+		From("user").
+		Join("post on post.user_id = user.id").
+		GroupBy("user.name").
+		Get(&test))
+	check(t, mock.ExpectationsWereMet())
+	equals(t, 1, len(test))
+	equals(t, UserPosts{Name: "foo", Posts: 3}, test[0])
+}
+
+func TestSelectQueryValueWithoutFrom(t *testing.T) {
+	db, _, err := getMockDB()
+	check(t, err)
+	var ids []int64
+	err = db.Select("id").Get(&ids)
+	if err == nil || !strings.Contains(err.Error(), "From") {
+		t.Fatalf("expected error about From, got %v", err)
+	}
+}
+
+func TestSelectQueryFromSQLite(t *testing.T) {
+	db := getSQLiteDB(t, "create table user (id integer not null primary key, name text not null, age integer not null)")
+	_, err := db.Exec("insert into user (id, name, age) values (1, 'foo', 20), (2, 'bar', 30)")
+	check(t, err)
+	var ids []int64
+	check(t, db.Select("id").From("user").OrderBy("id").Get(&ids))
+	equals(t, 2, len(ids))
+	equals(t, int64(2), ids[1])
+	var users []map[string]interface{}
+	check(t, db.Select("name", "age").From("user").OrderBy("id").Get(&users))
+	equals(t, 2, len(users))
+	equals(t, "foo", users[0]["name"])
+	equals(t, int64(30), users[1]["age"])
+	type Summary struct {
+		Total  int
+		MaxAge int
+	}
+	var summary Summary
+	check(t, db.Select("count(*) as total", "max(age) as max_age").From("user").Get(&summary))
+	equals(t, Summary{Total: 2, MaxAge: 30}, summary)
+}
@@ -1,12 +1,14 @@
 package gosql
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"reflect"
-	"strconv"
 	"strings"
+
+	"github.com/twharmon/gosql/expr"
 )
 
 type where struct {
@@ -14,6 +16,47 @@ type where struct {
 	condition   string
 }
 
+// writeWheres writes the where clause made of wheres and the extra
+// condition, which is left out if it is empty.
+func writeWheres(q *strings.Builder, wheres []*where, extra string) {
+	if len(wheres) == 0 {
+		if extra != "" {
+			q.WriteString(" where ")
+			q.WriteString(extra)
+		}
+		return
+	}
+	q.WriteString(" where ")
+	if extra != "" {
+		q.WriteString("(")
+	}
+	for i, where := range wheres {
+		if i > 0 {
+			q.WriteString(where.conjunction)
+		}
+		q.WriteString(where.condition)
+	}
+	if extra != "" {
+		q.WriteString(") and ")
+		q.WriteString(extra)
+	}
+}
+
+// toCondition returns the condition string and arguments of condition,
+// which is a string followed by args or an expr.Expr. Named parameters
+// of a string are bound from args. The args of an expr.Expr are
+// appended to its own.
+func toCondition(condition interface{}, args []interface{}) (string, []interface{}, error) {
+	switch c := condition.(type) {
+	case string:
+		return bindNamed(c, args)
+	case expr.Expr:
+		sql, exprArgs := c.SQL()
+		return sql, append(exprArgs[:len(exprArgs):len(exprArgs)], args...), nil
+	}
+	return "", nil, fmt.Errorf("condition must be a string or an expr.Expr (%T found)", condition)
+}
+
 type having struct {
 	conjunction string
 	condition   string
@@ -24,74 +67,116 @@ type Querier interface {
 	Query(query string, args ...interface{}) (*sql.Rows, error)
 }
 
+// QuerierContext .
+type QuerierContext interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
 // SelectQuery holds information for a select query.
 type SelectQuery struct {
 	db         *DB
-	querier    Querier
+	querier    QuerierContext
+	tx         *Tx
+	table      string
 	model      *model
 	fields     []string
 	joins      []string
+	joinArgs   []interface{}
 	wheres     []*where
 	whereArgs  []interface{}
 	havings    []*having
 	havingArgs []interface{}
+	seek       string
+	seekArgs   []interface{}
 	groupBy    string
 	order      string
 	many       bool
 	limit      int64
 	offset     int64
+	softModel  *model
+	softDelete softDeleteMode
+	preloads   []string
+	err        error
+}
+
+// setErr records the first error found while building the query, which
+// is returned when the query is run.
+func (sq *SelectQuery) setErr(err error) {
+	if sq.err == nil {
+		sq.err = err
+	}
 }
 
-// Join joins another table to this query.
-func (sq *SelectQuery) Join(join string) *SelectQuery {
+// Join joins another table to this query. Named parameters in join are
+// bound from args.
+func (sq *SelectQuery) Join(join string, args ...interface{}) *SelectQuery {
+	join, args, err := bindNamed(join, args)
+	sq.setErr(err)
 	sq.joins = append(sq.joins, fmt.Sprintf(" join %s", join))
+	sq.joinArgs = append(sq.joinArgs, args...)
 	return sq
 }
 
-// LeftJoin joins another table to this query.
-func (sq *SelectQuery) LeftJoin(join string) *SelectQuery {
+// LeftJoin joins another table to this query. Named parameters in join
+// are bound from args.
+func (sq *SelectQuery) LeftJoin(join string, args ...interface{}) *SelectQuery {
+	join, args, err := bindNamed(join, args)
+	sq.setErr(err)
 	sq.joins = append(sq.joins, fmt.Sprintf(" left join %s", join))
+	sq.joinArgs = append(sq.joinArgs, args...)
 	return sq
 }
 
-// Where specifies which rows will be returned.
-func (sq *SelectQuery) Where(condition string, args ...interface{}) *SelectQuery {
+// Where specifies which rows will be returned. The condition is a
+// string or an expr.Expr.
+func (sq *SelectQuery) Where(condition interface{}, args ...interface{}) *SelectQuery {
+	c, args, err := toCondition(condition, args)
+	sq.setErr(err)
 	w := &where{
 		conjunction: " and ",
-		condition:   condition,
+		condition:   c,
 	}
 	sq.wheres = append(sq.wheres, w)
 	sq.whereArgs = append(sq.whereArgs, args...)
 	return sq
 }
 
-// Having specifies which rows will be returned.
-func (sq *SelectQuery) Having(condition string, args ...interface{}) *SelectQuery {
+// Having specifies which rows will be returned. The condition is a
+// string or an expr.Expr.
+func (sq *SelectQuery) Having(condition interface{}, args ...interface{}) *SelectQuery {
+	c, args, err := toCondition(condition, args)
+	sq.setErr(err)
 	h := &having{
 		conjunction: " and ",
-		condition:   condition,
+		condition:   c,
 	}
 	sq.havings = append(sq.havings, h)
 	sq.havingArgs = append(sq.havingArgs, args...)
 	return sq
 }
 
-// OrWhere specifies which rows will be returned.
-func (sq *SelectQuery) OrWhere(condition string, args ...interface{}) *SelectQuery {
+// OrWhere specifies which rows will be returned. The condition is a
+// string or an expr.Expr.
+func (sq *SelectQuery) OrWhere(condition interface{}, args ...interface{}) *SelectQuery {
+	c, args, err := toCondition(condition, args)
+	sq.setErr(err)
 	w := &where{
 		conjunction: " or ",
-		condition:   condition,
+		condition:   c,
 	}
 	sq.wheres = append(sq.wheres, w)
 	sq.whereArgs = append(sq.whereArgs, args...)
 	return sq
 }
 
-// OrHaving specifies which rows will be returned.
-func (sq *SelectQuery) OrHaving(condition string, args ...interface{}) *SelectQuery {
+// OrHaving specifies which rows will be returned. The condition is a
+// string or an expr.Expr.
+func (sq *SelectQuery) OrHaving(condition interface{}, args ...interface{}) *SelectQuery {
+	c, args, err := toCondition(condition, args)
+	sq.setErr(err)
 	h := &having{
 		conjunction: " or ",
-		condition:   condition,
+		condition:   c,
 	}
 	sq.havings = append(sq.havings, h)
 	sq.havingArgs = append(sq.havingArgs, args...)
@@ -122,89 +207,185 @@ func (sq *SelectQuery) Offset(offset int64) *SelectQuery {
 	return sq
 }
 
-// Get sets the result of the query to out. Get() can only take a pointer
-// to a struct, a pointer to a slice of structs, or a pointer to a slice
-// of pointers to structs.
+// WithDeleted makes the query return soft deleted rows as well.
+func (sq *SelectQuery) WithDeleted() *SelectQuery {
+	sq.softDelete = withDeleted
+	return sq
+}
+
+// OnlyDeleted makes the query return only soft deleted rows. If the
+// table is set by From, it must be the table of the model selected
+// into or the model must be set by Model.
+func (sq *SelectQuery) OnlyDeleted() *SelectQuery {
+	sq.softDelete = onlyDeleted
+	return sq
+}
+
+// Model sets the model whose soft deleted rows are left out of the
+// query, which must be a pointer to a model struct. It is only needed
+// if the table is set by From and differs from the table of the struct
+// selected into, such as when selecting values or maps.
+func (sq *SelectQuery) Model(obj interface{}) *SelectQuery {
+	m, err := sq.db.getModelOf(reflect.TypeOf(obj))
+	sq.setErr(err)
+	sq.softModel = m
+	return sq
+}
+
+// getSoftModel returns the model whose soft deleted rows are left out
+// of the query, or nil if there is none.
+func (sq *SelectQuery) getSoftModel() *model {
+	if sq.softModel != nil {
+		return sq.softModel
+	}
+	if sq.model != nil && (sq.table == "" || sq.table == sq.model.table) {
+		return sq.model
+	}
+	return nil
+}
+
+// Get sets the result of the query to out. Get() takes a pointer to a
+// model struct, a slice of model structs, or a slice of pointers to
+// model structs. If the table is set by From, it also takes a pointer
+// to a struct that is not a model, a value scanned from the only
+// selected column, a map[string]interface{} of columns to values, or a
+// slice of any of these.
 func (sq *SelectQuery) Get(out interface{}) error {
+	return sq.GetContext(context.Background(), out)
+}
+
+// GetContext is like Get, but uses the given context for the query.
+func (sq *SelectQuery) GetContext(ctx context.Context, out interface{}) error {
+	if sq.err != nil {
+		return sq.err
+	}
 	t := reflect.TypeOf(out)
-	if t.Kind() != reflect.Ptr {
+	if t == nil || t.Kind() != reflect.Ptr {
 		return fmt.Errorf("out must be a pointer")
 	}
 	t = t.Elem()
-	switch t.Kind() {
-	case reflect.Struct:
-		var err error
-		sq.model, err = sq.db.getModelOf(t)
-		if err != nil {
+	switch {
+	case t == mapType:
+		if err := sq.mustHaveTable(t); err != nil {
 			return err
 		}
-		return sq.toOne(out)
-	case reflect.Slice:
+		return sq.toMap(ctx, out.(*map[string]interface{}))
+	case isStructTarget(t):
+		if err := sq.setModel(t); err != nil {
+			return err
+		}
+		if err := sq.toOne(ctx, out); err != nil {
+			return err
+		}
+		return sq.preloadInto(ctx, out)
+	case t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8:
 		el := t.Elem()
-		switch el.Kind() {
-		case reflect.Ptr:
-			el = el.Elem()
-			if el.Kind() != reflect.Struct {
-				break
+		switch {
+		case el == mapType:
+			if err := sq.mustHaveTable(t); err != nil {
+				return err
+			}
+			return sq.toMaps(ctx, out.(*[]map[string]interface{}))
+		case el.Kind() == reflect.Ptr && isStructTarget(el.Elem()):
+			if err := sq.setModel(el.Elem()); err != nil {
+				return err
 			}
-			var err error
-			sq.model, err = sq.db.getModelOf(el)
-			if err != nil {
+			if err := sq.toMany(ctx, t, out); err != nil {
 				return err
 			}
-			return sq.toMany(t, out)
-		case reflect.Struct:
-			var err error
-			sq.model, err = sq.db.getModelOf(el)
-			if err != nil {
+			return sq.preloadInto(ctx, out)
+		case isStructTarget(el):
+			if err := sq.setModel(el); err != nil {
 				return err
 			}
-			return sq.toManyValues(t, out)
+			if err := sq.toManyValues(ctx, t, out); err != nil {
+				return err
+			}
+			return sq.preloadInto(ctx, out)
+		}
+		if err := sq.mustHaveTable(t); err != nil {
+			return err
 		}
+		return sq.toValues(ctx, t, out)
+	}
+	if err := sq.mustHaveTable(t); err != nil {
+		return err
+	}
+	return sq.toValue(ctx, out)
+}
+
+// setModel sets the model of the query to the model of the struct type
+// t. If the table is set by From, t does not need to be a valid model.
+func (sq *SelectQuery) setModel(t reflect.Type) error {
+	var err error
+	if sq.table != "" {
+		sq.model, err = sq.db.getScanModel(t)
+	} else {
+		sq.model, err = sq.db.getModelOf(t)
+	}
+	return err
+}
+
+func (sq *SelectQuery) mustHaveTable(t reflect.Type) error {
+	if sq.table == "" {
+		return fmt.Errorf("out must be a struct, slice of structs, or slice of pointers to structs unless the table is set by From (%s found)", t)
 	}
-	return fmt.Errorf("out must be a struct, slice of structs, or slice of pointers to structs (%s found)", t.Kind().String())
+	return nil
+}
+
+// preloadInto loads the preloaded relations into out, which holds the
+// models selected by the query.
+func (sq *SelectQuery) preloadInto(ctx context.Context, out interface{}) error {
+	if len(sq.preloads) == 0 {
+		return nil
+	}
+	v := reflect.ValueOf(out).Elem()
+	if v.Kind() != reflect.Slice {
+		return sq.preload(ctx, sq.model, []reflect.Value{v}, sq.preloads)
+	}
+	values := make([]reflect.Value, v.Len())
+	for i := range values {
+		values[i] = reflect.Indirect(v.Index(i))
+	}
+	return sq.preload(ctx, sq.model, values, sq.preloads)
 }
 
-func (sq *SelectQuery) toOne(out interface{}) error {
+func (sq *SelectQuery) toOne(ctx context.Context, out interface{}) error {
 	e := reflect.ValueOf(out).Elem()
 	if !e.IsValid() {
 		return errors.New("out must not be a nil pointer")
 	}
-	args := sq.whereArgs
-	args = append(args, sq.havingArgs...)
-	rows, err := sq.querier.Query(sq.String(), args...)
+	rows, err := sq.query(ctx)
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
 	columns, _ := rows.Columns()
-	fieldCount := len(columns)
+	fieldIndecies, err := sq.model.getScanFieldIndecies(columns)
+	if err != nil {
+		return err
+	}
 	found := false
 	for rows.Next() {
-		dests := make([]interface{}, fieldCount)
-		for j := 0; j < fieldCount; j++ {
-			fieldIdx := sq.model.getFieldIndexByName(columns[j])
-			if fieldIdx < 0 {
-				return fmt.Errorf("no field for column %s", columns[j])
-			}
-			dests[j] = e.Field(fieldIdx).Addr().Interface()
+		dests := make([]interface{}, len(columns))
+		for j, fieldIdx := range fieldIndecies {
+			dests[j] = sq.model.fieldAddr(e, fieldIdx)
 		}
 		if err := rows.Scan(dests...); err != nil {
 			return err
 		}
+		sq.model.takeSnapshot(e)
 		found = true
 	}
 	if !found {
 		return ErrNotFound
 	}
-	return nil
+	return runHook(afterFind, e, sq.tx)
 }
 
-func (sq *SelectQuery) toMany(sliceType reflect.Type, outs interface{}) error {
+func (sq *SelectQuery) toMany(ctx context.Context, sliceType reflect.Type, outs interface{}) error {
 	sq.many = true
-	args := sq.whereArgs
-	args = append(args, sq.havingArgs...)
-	rows, err := sq.querier.Query(sq.String(), args...)
+	rows, err := sq.query(ctx)
 	if err != nil {
 		return err
 	}
@@ -218,27 +399,37 @@ func (sq *SelectQuery) toMany(sliceType reflect.Type, outs interface{}) error {
 	i := 0
 	columns, _ := rows.Columns()
 	fieldCount := len(columns)
-	fieldIndecies := make([]int, fieldCount)
-	for j := 0; j < fieldCount; j++ {
-		fieldIndecies[j] = sq.model.getFieldIndexByName(columns[j])
-		if fieldIndecies[j] < 0 {
-			return fmt.Errorf("no field for column %s", columns[j])
-		}
+	fieldIndecies, err := sq.model.getScanFieldIndecies(columns)
+	if err != nil {
+		return err
 	}
 	dests := make([]interface{}, fieldCount)
+	blockType := reflect.SliceOf(sq.model.typ)
+	var block reflect.Value
+	blockIdx := 0
 	for rows.Next() {
 		if newOuts.Len() == i {
-			newOuts.SetCap(newOuts.Len() * 2)
-			newOuts.SetLen(newOuts.Len() * 2)
+			newOuts = reflect.Append(newOuts, reflect.Zero(sliceType.Elem()))
+			newOuts = newOuts.Slice(0, newOuts.Cap())
+		}
+		if !block.IsValid() || blockIdx == block.Len() {
+			size := sq.getBlockSize(i)
+			block = reflect.MakeSlice(blockType, size, size)
+			blockIdx = 0
 		}
 		newOut := newOuts.Index(i)
-		newOut.Set(reflect.New(sq.model.typ))
+		newOut.Set(block.Index(blockIdx).Addr())
+		blockIdx++
 		for j := 0; j < fieldCount; j++ {
-			dests[j] = newOut.Elem().Field(fieldIndecies[j]).Addr().Interface()
+			dests[j] = sq.model.fieldAddr(newOut.Elem(), fieldIndecies[j])
 		}
 		if err := rows.Scan(dests...); err != nil {
 			return err
 		}
+		sq.model.takeSnapshot(newOut.Elem())
+		if err := runHook(afterFind, newOut.Elem(), sq.tx); err != nil {
+			return err
+		}
 		i++
 	}
 	v := reflect.Indirect(reflect.ValueOf(outs))
@@ -247,11 +438,9 @@ func (sq *SelectQuery) toMany(sliceType reflect.Type, outs interface{}) error {
 	return nil
 }
 
-func (sq *SelectQuery) toManyValues(sliceType reflect.Type, outs interface{}) error {
+func (sq *SelectQuery) toManyValues(ctx context.Context, sliceType reflect.Type, outs interface{}) error {
 	sq.many = true
-	args := sq.whereArgs
-	args = append(args, sq.havingArgs...)
-	rows, err := sq.querier.Query(sq.String(), args...)
+	rows, err := sq.query(ctx)
 	if err != nil {
 		return err
 	}
@@ -265,27 +454,28 @@ func (sq *SelectQuery) toManyValues(sliceType reflect.Type, outs interface{}) er
 	i := 0
 	columns, _ := rows.Columns()
 	fieldCount := len(columns)
-	fieldIndecies := make([]int, fieldCount)
-	for j := 0; j < fieldCount; j++ {
-		fieldIndecies[j] = sq.model.getFieldIndexByName(columns[j])
-		if fieldIndecies[j] < 0 {
-			return fmt.Errorf("no field for column %s", columns[j])
-		}
+	fieldIndecies, err := sq.model.getScanFieldIndecies(columns)
+	if err != nil {
+		return err
 	}
 	dests := make([]interface{}, fieldCount)
 	newOut := newOuts.Index(0)
 	for rows.Next() {
 		if newOuts.Len() == i {
-			newOuts.SetCap(newOuts.Len() * 2)
-			newOuts.SetLen(newOuts.Len() * 2)
+			newOuts = reflect.Append(newOuts, reflect.Zero(sliceType.Elem()))
+			newOuts = newOuts.Slice(0, newOuts.Cap())
 		}
 		newOut = newOuts.Index(i)
 		for j := 0; j < fieldCount; j++ {
-			dests[j] = newOut.Field(fieldIndecies[j]).Addr().Interface()
+			dests[j] = sq.model.fieldAddr(newOut, fieldIndecies[j])
 		}
 		if err := rows.Scan(dests...); err != nil {
 			return err
 		}
+		sq.model.takeSnapshot(newOut)
+		if err := runHook(afterFind, newOut, sq.tx); err != nil {
+			return err
+		}
 		i++
 	}
 	v := reflect.Indirect(reflect.ValueOf(outs))
@@ -294,8 +484,38 @@ func (sq *SelectQuery) toManyValues(sliceType reflect.Type, outs interface{}) er
 	return nil
 }
 
+// maxBlockSize is the maximum number of models allocated at once when
+// selecting into a slice of pointers. Blocks are kept small so a
+// pointer that outlives the rest of the slice keeps little memory
+// alive.
+const maxBlockSize = 64
+
+// getBlockSize returns the number of models to allocate at once after
+// i rows have been scanned into a slice of pointers.
+func (sq *SelectQuery) getBlockSize(i int) int {
+	if n := sq.limit - int64(i); n > 0 && n < maxBlockSize {
+		return int(n)
+	}
+	return maxBlockSize
+}
+
+// args returns the arguments of the query in the order of their
+// placeholders.
+func (sq *SelectQuery) args() []interface{} {
+	args := make([]interface{}, 0, len(sq.joinArgs)+len(sq.whereArgs)+len(sq.seekArgs)+len(sq.havingArgs))
+	args = append(args, sq.joinArgs...)
+	args = append(args, sq.whereArgs...)
+	args = append(args, sq.seekArgs...)
+	return append(args, sq.havingArgs...)
+}
+
 // String returns the string representation of SelectQuery.
 func (sq *SelectQuery) String() string {
+	return rebind(sq.db.dialect, sq.sql())
+}
+
+// sql returns the query with ? placeholders.
+func (sq *SelectQuery) sql() string {
 	var q strings.Builder
 	q.WriteString("select ")
 	for i := 0; i < len(sq.fields)-1; i++ {
@@ -303,19 +523,39 @@ func (sq *SelectQuery) String() string {
 		q.WriteString(", ")
 	}
 	q.WriteString(sq.fields[len(sq.fields)-1])
+	sq.writeFrom(&q)
+	if sq.order != "" {
+		q.WriteString(" order by ")
+		q.WriteString(sq.order)
+	}
+	limit := int64(1)
+	if sq.many {
+		limit = sq.limit
+	}
+	q.WriteString(sq.db.dialect.Limit(limit, sq.offset))
+	return q.String()
+}
+
+// writeFrom writes the from, join, where, group by and having clauses
+// of the query.
+func (sq *SelectQuery) writeFrom(q *strings.Builder) {
 	q.WriteString(" from ")
-	q.WriteString(sq.model.table)
+	if sq.table != "" {
+		q.WriteString(sq.table)
+	} else {
+		q.WriteString(sq.db.dialect.Quote(sq.model.table))
+	}
 	for _, join := range sq.joins {
 		q.WriteString(join)
 	}
-	for i, where := range sq.wheres {
-		if i == 0 {
-			q.WriteString(" where ")
-		} else {
-			q.WriteString(where.conjunction)
+	extra := sq.getSoftModel().getSoftDeleteCondition(sq.softDelete)
+	if sq.seek != "" {
+		if extra != "" {
+			extra += " and "
 		}
-		q.WriteString(where.condition)
+		extra += sq.seek
 	}
+	writeWheres(q, sq.wheres, extra)
 
 	if sq.groupBy != "" {
 		q.WriteString(" group by ")
@@ -330,22 +570,4 @@ func (sq *SelectQuery) String() string {
 		}
 		q.WriteString(having.condition)
 	}
-
-	if sq.order != "" {
-		q.WriteString(" order by ")
-		q.WriteString(sq.order)
-	}
-	if sq.many {
-		if sq.limit > 0 {
-			q.WriteString(" limit ")
-			q.WriteString(strconv.FormatInt(sq.limit, 10))
-		}
-	} else {
-		q.WriteString(" limit 1")
-	}
-	if sq.offset > 0 {
-		q.WriteString(" offset ")
-		q.WriteString(strconv.FormatInt(sq.offset, 10))
-	}
-	return q.String()
 }
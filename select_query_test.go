@@ -1,6 +1,7 @@
 package gosql_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -327,3 +328,97 @@ func TestSelectNoColumn(t *testing.T) {
 	}
 	check(t, mock.ExpectationsWereMet())
 }
+
+func TestSelectQueryGetContext(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	control := T{
+		ID:   5,
+		Name: "foo",
+	}
+	rows := sqlmock.NewRows([]string{"id", "name"})
+	rows.AddRow(control.ID, control.Name)
+	mock.ExpectQuery(`^select \* from t where id = \? limit 1$`).WithArgs(control.ID).WillReturnRows(rows)
+	var test T
+	check(t, db.Select("*").Where("id = ?", control.ID).GetContext(context.Background(), &test))
+	check(t, mock.ExpectationsWereMet())
+	equals(t, control, test)
+}
+
+func TestSelectQueryGetContextCanceled(t *testing.T) {
+	db, _, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var test []T
+	if err := db.Select("*").GetContext(ctx, &test); err != context.Canceled {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestSelectQueryManyGrows(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID int `idx:"primary"`
+	}
+	rows := sqlmock.NewRows([]string{"id"})
+	for i := 0; i < 20; i++ {
+		rows.AddRow(i)
+	}
+	mock.ExpectQuery(`^select \* from t limit 2$`).WillReturnRows(rows)
+	var test []*T
+	check(t, db.Select("*").Limit(2).Get(&test))
+	check(t, mock.ExpectationsWereMet())
+	equals(t, 20, len(test))
+	equals(t, 19, test[19].ID)
+}
+
+func TestSelectQueryManyValuesGrows(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID int `idx:"primary"`
+	}
+	rows := sqlmock.NewRows([]string{"id"})
+	for i := 0; i < 20; i++ {
+		rows.AddRow(i)
+	}
+	mock.ExpectQuery(`^select \* from t$`).WillReturnRows(rows)
+	var test []T
+	check(t, db.Select("*").Get(&test))
+	check(t, mock.ExpectationsWereMet())
+	equals(t, 20, len(test))
+	equals(t, 19, test[19].ID)
+}
+
+func TestSelectQueryManyPtrsBlocks(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	rows := sqlmock.NewRows([]string{"id", "name"})
+	for i := 0; i < 150; i++ {
+		rows.AddRow(i, "foo")
+	}
+	mock.ExpectQuery(`^select \* from t$`).WillReturnRows(rows)
+	var test []*T
+	check(t, db.Select("*").Get(&test))
+	check(t, mock.ExpectationsWereMet())
+	equals(t, 150, len(test))
+	for i, obj := range test {
+		equals(t, i, obj.ID)
+	}
+	test[0].Name = "bar"
+	equals(t, "foo", test[1].Name)
+}
@@ -0,0 +1,91 @@
+package gosql
+
+import (
+	"bytes"
+	"reflect"
+)
+
+// Snapshot can be embedded in a model to track which of its fields
+// change. A snapshot of the fields is taken when the model is loaded by
+// SelectQuery.Get, inserted by Insert, InsertMany or Upsert, or updated,
+// and Update then only writes the columns that changed since. Models
+// without a snapshot, or that have not been loaded, have all of their
+// columns written by Update. An Upsert that updates only some columns
+// refreshes the snapshot of those columns alone, and one that does
+// nothing on conflict leaves the snapshot as it is.
+type Snapshot struct {
+	values []interface{}
+}
+
+var snapshotType = reflect.TypeOf(Snapshot{})
+
+// changed reports whether f, the field at index i of the model, differs
+// from its value in the snapshot.
+func (s *Snapshot) changed(f reflect.Value, i int) bool {
+	if b, ok := s.values[i].([]byte); ok {
+		return !bytes.Equal(b, f.Bytes())
+	}
+	return !reflect.DeepEqual(s.values[i], f.Interface())
+}
+
+// getSnapshot returns the snapshot of v, or nil if the model has no
+// snapshot or v has not been loaded.
+func (m *model) getSnapshot(v reflect.Value) *Snapshot {
+	if m.snapshotIndex == nil {
+		return nil
+	}
+	s := m.snapshotField(v)
+	if len(s.values) != len(m.fields) {
+		return nil
+	}
+	return s
+}
+
+func (m *model) snapshotField(v reflect.Value) *Snapshot {
+	for j, x := range m.snapshotIndex {
+		if j > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v.Addr().Interface().(*Snapshot)
+}
+
+// takeSnapshot saves the values of all fields of v in its snapshot.
+func (m *model) takeSnapshot(v reflect.Value) {
+	if m.snapshotIndex == nil {
+		return
+	}
+	s := m.snapshotField(v)
+	s.values = make([]interface{}, len(m.fields))
+	for i := range m.fields {
+		s.values[i] = snapshotValue(m.fieldValue(v, i))
+	}
+}
+
+// refreshSnapshot saves the values of the fields of v at the given
+// indecies in its snapshot, if v has been loaded. The values are copied
+// to a new slice, since copies of the model share the old one.
+func (m *model) refreshSnapshot(v reflect.Value, fieldIndecies []int) {
+	s := m.getSnapshot(v)
+	if s == nil {
+		return
+	}
+	values := append([]interface{}(nil), s.values...)
+	for _, i := range fieldIndecies {
+		values[i] = snapshotValue(m.fieldValue(v, i))
+	}
+	s.values = values
+}
+
+// snapshotValue returns the value of f, copying byte slices so that
+// changes made to them in place are noticed.
+func snapshotValue(f reflect.Value) interface{} {
+	if f.Kind() == reflect.Slice && f.Type().Elem().Kind() == reflect.Uint8 {
+		return append([]byte(nil), f.Bytes()...)
+	}
+	return f.Interface()
+}
@@ -0,0 +1,137 @@
+package gosql_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/twharmon/gosql"
+)
+
+type tracked struct {
+	gosql.Snapshot
+	ID    int `idx:"primary"`
+	Name  string
+	Email string
+	Data  []byte
+}
+
+func TestSnapshotUpdateChanged(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	rows := sqlmock.NewRows([]string{"id", "name", "email", "data"})
+	rows.AddRow(5, "foo", "foo@example.com", []byte("a"))
+	mock.ExpectQuery(`^select \* from tracked limit 1$`).WillReturnRows(rows)
+	mock.ExpectExec(`^update tracked set email = \?, data = \? where id = \?$`).WithArgs("bar@example.com", []byte("b"), 5).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`^update tracked set name = \? where id = \?$`).WithArgs("bar", 5).WillReturnResult(sqlmock.NewResult(0, 1))
+	var model tracked
+	check(t, db.Select("*").Get(&model))
+	model.Email = "bar@example.com"
+	model.Data[0] = 'b'
+	_, err = db.Update(&model)
+	check(t, err)
+	model.Name = "bar"
+	_, err = db.Update(&model)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestSnapshotUpdateUnchanged(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	rows := sqlmock.NewRows([]string{"id", "name", "email", "data"})
+	rows.AddRow(5, "foo", "foo@example.com", nil)
+	mock.ExpectQuery(`^select \* from tracked$`).WillReturnRows(rows)
+	var models []*tracked
+	check(t, db.Select("*").Get(&models))
+	res, err := db.Update(models[0])
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	affected, err := res.RowsAffected()
+	check(t, err)
+	equals(t, int64(0), affected)
+}
+
+func TestSnapshotCopy(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	rows := sqlmock.NewRows([]string{"id", "name", "email", "data"})
+	rows.AddRow(5, "foo", "foo@example.com", nil)
+	mock.ExpectQuery(`^select \* from tracked limit 1$`).WillReturnRows(rows)
+	mock.ExpectExec(`^update tracked set name = \? where id = \?$`).WithArgs("bar", 5).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`^update tracked set name = \? where id = \?$`).WithArgs("bar", 5).WillReturnResult(sqlmock.NewResult(0, 1))
+	var a tracked
+	check(t, db.Select("*").Get(&a))
+	b := a
+	b.Name = "bar"
+	_, err = db.Update(&b)
+	check(t, err)
+	a.Name = "bar"
+	_, err = db.Update(&a)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestSnapshotNotLoaded(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	model := tracked{ID: 5, Name: "foo"}
+	mock.ExpectExec(`^update tracked set name = \?, email = \?, data = \? where id = \?$`).WithArgs("foo", "", []byte(nil), 5).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.Update(&model)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestSnapshotAfterInsert(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	model := tracked{Name: "foo"}
+	mock.ExpectExec(`^insert into tracked \(name, email, data\) values \(\?, \?, \?\)$`).WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectExec(`^update tracked set email = \? where id = \?$`).WithArgs("foo@example.com", 7).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.Insert(&model)
+	check(t, err)
+	model.Email = "foo@example.com"
+	_, err = db.Update(&model)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestSnapshotAfterInsertMany(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	models := []tracked{{ID: 1, Name: "foo"}, {ID: 2, Name: "bar"}}
+	mock.ExpectExec(`^insert into tracked \(id, name, email, data\) values \(\?, \?, \?, \?\), \(\?, \?, \?, \?\)$`).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`^update tracked set email = \? where id = \?$`).WithArgs("bar@example.com", 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.InsertMany(models)
+	check(t, err)
+	models[1].Email = "bar@example.com"
+	_, err = db.Update(&models[1])
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestSnapshotAfterUpsert(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	model := tracked{ID: 5, Name: "foo"}
+	mock.ExpectExec(`^insert into tracked \(id, name, email, data\) values \(\?, \?, \?, \?\) on duplicate key update `).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`^update tracked set email = \? where id = \?$`).WithArgs("foo@example.com", 5).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.Upsert(&model, nil)
+	check(t, err)
+	model.Email = "foo@example.com"
+	_, err = db.Update(&model)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestSnapshotAfterUpsertColumns(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	model := tracked{ID: 5, Name: "foo"}
+	mock.ExpectExec(`^insert into tracked \(id, name, email, data\) values \(\?, \?, \?, \?\) on duplicate key update name = values\(name\)$`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`^update tracked set name = \?, email = \?, data = \? where id = \?$`).WithArgs("foo", "", []byte(nil), 5).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.Upsert(&model, &gosql.UpsertOptions{Columns: []string{"name"}})
+	check(t, err)
+	_, err = db.Update(&model)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
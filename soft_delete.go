@@ -0,0 +1,109 @@
+package gosql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// softDeleteMode controls which rows of a model with a field tagged
+// `soft:"delete"` are returned by a query.
+type softDeleteMode int
+
+const (
+	excludeDeleted softDeleteMode = iota
+	withDeleted
+	onlyDeleted
+)
+
+var nullTimeType = reflect.TypeOf(NullTime{})
+
+// errNoSoftDelete is returned when restoring a model that has no field
+// tagged `soft:"delete"`.
+var errNoSoftDelete = errors.New("model must have a field tagged `soft:\"delete\"`")
+
+// errNoSoftModel is returned when only soft deleted rows are queried
+// from a table that has no model.
+var errNoSoftModel = errors.New("model must be set by Model to query only soft deleted rows")
+
+// getSoftDeleteCondition returns the condition selecting the rows of
+// the model in the given mode, or an empty string if all rows are
+// selected.
+func (m *model) getSoftDeleteCondition(mode softDeleteMode) string {
+	if m == nil || m.softDeleteFieldIndex < 0 || mode == withDeleted {
+		return ""
+	}
+	var q strings.Builder
+	q.WriteString(m.dialect.Quote(m.table))
+	q.WriteString(".")
+	q.WriteString(m.dialect.Quote(m.fields[m.softDeleteFieldIndex].column))
+	if mode == onlyDeleted {
+		q.WriteString(" is not null")
+	} else {
+		q.WriteString(" is null")
+	}
+	return q.String()
+}
+
+// softDelete sets the field tagged `soft:"delete"` of v to now in the
+// database and on v.
+func (m *model) softDelete(ctx context.Context, e ExecerContext, v reflect.Value, now time.Time) (sql.Result, error) {
+	deletedAt := NullTime{Time: now, Valid: true}
+	args := append([]interface{}{deletedAt}, m.getPrimaryArgs(v)...)
+	res, err := e.ExecContext(ctx, m.getSoftDeleteQuery(), args...)
+	if err != nil {
+		return nil, err
+	}
+	m.settableField(v, m.softDeleteFieldIndex).Set(reflect.ValueOf(deletedAt))
+	m.refreshSnapshot(v, []int{m.softDeleteFieldIndex})
+	return res, nil
+}
+
+// restore sets the field tagged `soft:"delete"` of v to null in the
+// database and on v.
+func (m *model) restore(ctx context.Context, e ExecerContext, v reflect.Value) (sql.Result, error) {
+	if m.softDeleteFieldIndex < 0 {
+		return nil, errNoSoftDelete
+	}
+	res, err := e.ExecContext(ctx, m.getRestoreQuery(), m.getPrimaryArgs(v)...)
+	if err != nil {
+		return nil, err
+	}
+	m.settableField(v, m.softDeleteFieldIndex).Set(reflect.ValueOf(NullTime{}))
+	m.refreshSnapshot(v, []int{m.softDeleteFieldIndex})
+	return res, nil
+}
+
+func (m *model) getSoftDeleteQuery() string {
+	return m.getCachedQuery(softDeleteKey{}, m.buildSoftDeleteQuery)
+}
+
+func (m *model) buildSoftDeleteQuery() string {
+	var query strings.Builder
+	query.WriteString("update ")
+	query.WriteString(m.dialect.Quote(m.table))
+	query.WriteString(" set ")
+	query.WriteString(m.dialect.Quote(m.fields[m.softDeleteFieldIndex].column))
+	query.WriteString(" = ")
+	query.WriteString(m.dialect.Placeholder(1))
+	m.writePrimaryWhere(&query, 1)
+	return query.String()
+}
+
+func (m *model) getRestoreQuery() string {
+	return m.getCachedQuery(restoreKey{}, m.buildRestoreQuery)
+}
+
+func (m *model) buildRestoreQuery() string {
+	var query strings.Builder
+	query.WriteString("update ")
+	query.WriteString(m.dialect.Quote(m.table))
+	query.WriteString(" set ")
+	query.WriteString(m.dialect.Quote(m.fields[m.softDeleteFieldIndex].column))
+	query.WriteString(" = null")
+	m.writePrimaryWhere(&query, 0)
+	return query.String()
+}
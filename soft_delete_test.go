@@ -0,0 +1,202 @@
+package gosql_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/twharmon/gosql"
+)
+
+type softPost struct {
+	ID        int `idx:"primary"`
+	Body      string
+	DeletedAt gosql.NullTime `soft:"delete"`
+}
+
+func TestSoftDelete(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	post := softPost{ID: 5, Body: "foo"}
+	mock.ExpectExec(`^update soft_post set deleted_at = \? where id = \?$`).WithArgs(sqlmock.AnyArg(), post.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.Delete(&post)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	equals(t, post.DeletedAt.Valid, true)
+}
+
+func TestSoftDeleteTx(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	post := softPost{ID: 5, Body: "foo"}
+	mock.ExpectBegin()
+	mock.ExpectExec(`^update soft_post set deleted_at = \? where id = \?$`).WithArgs(sqlmock.AnyArg(), post.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	tx, err := db.Begin()
+	check(t, err)
+	_, err = tx.Delete(&post)
+	check(t, err)
+	check(t, tx.Commit())
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestSoftDeleteHardDelete(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	post := softPost{ID: 5, Body: "foo"}
+	mock.ExpectExec(`^delete from soft_post where id = \?$`).WithArgs(post.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.HardDelete(&post)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestSoftDeleteRestore(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	post := softPost{ID: 5, Body: "foo"}
+	post.DeletedAt.Valid = true
+	mock.ExpectExec(`^update soft_post set deleted_at = null where id = \?$`).WithArgs(post.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.Restore(&post)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	equals(t, post.DeletedAt.Valid, false)
+}
+
+func TestSoftDeleteRestoreWithoutTag(t *testing.T) {
+	db, _, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID int `idx:"primary"`
+	}
+	if _, err := db.Restore(&T{ID: 5}); err == nil {
+		t.Fatal("expected error restoring model without soft delete field")
+	}
+}
+
+func TestSoftDeleteWrongType(t *testing.T) {
+	db, _, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID        int    `idx:"primary"`
+		DeletedAt string `soft:"delete"`
+	}
+	if err := db.Register(T{}); err == nil {
+		t.Fatal("expected error registering soft delete field that is not a NullTime")
+	}
+}
+
+func TestSoftDeleteSelect(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectQuery(`^select \* from soft_post where \(body = \? or id = \?\) and soft_post\.deleted_at is null limit 1$`).WithArgs("foo", 5).WillReturnRows(sqlmock.NewRows([]string{"id", "body", "deleted_at"}).AddRow(5, "foo", nil))
+	var post softPost
+	check(t, db.Select("*").Where("body = ?", "foo").OrWhere("id = ?", 5).Get(&post))
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestSoftDeleteSelectMany(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectQuery(`^select \* from soft_post where soft_post\.deleted_at is null$`).WillReturnRows(sqlmock.NewRows([]string{"id", "body", "deleted_at"}).AddRow(5, "foo", nil))
+	var posts []softPost
+	check(t, db.Select("*").Get(&posts))
+	check(t, mock.ExpectationsWereMet())
+	equals(t, len(posts), 1)
+}
+
+func TestSoftDeleteSelectWithDeleted(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectQuery(`^select \* from soft_post$`).WillReturnRows(sqlmock.NewRows([]string{"id", "body", "deleted_at"}))
+	var posts []softPost
+	check(t, db.Select("*").WithDeleted().Get(&posts))
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestSoftDeleteSelectOnlyDeleted(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectQuery(`^select \* from soft_post where soft_post\.deleted_at is not null$`).WillReturnRows(sqlmock.NewRows([]string{"id", "body", "deleted_at"}))
+	var posts []softPost
+	check(t, db.Select("*").OnlyDeleted().Get(&posts))
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestSoftDeleteCount(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectQuery(`^select count\(\*\) from soft_post where \(body = \?\) and soft_post\.deleted_at is null$`).WithArgs("foo").WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(3))
+	count, err := db.Count("soft_post", "*").Model(&softPost{}).Where("body = ?", "foo").Exec()
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	equals(t, count, int64(3))
+}
+
+func TestSoftDeleteCountOnlyDeleted(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectQuery(`^select count\(\*\) from soft_post where soft_post\.deleted_at is not null$`).WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(1))
+	count, err := db.Count("soft_post", "*").Model(&softPost{}).OnlyDeleted().Exec()
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+	equals(t, count, int64(1))
+}
+
+func TestSoftDeleteCountWithoutModel(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	// registering the model of the table does not change the query
+	for i := 0; i < 2; i++ {
+		mock.ExpectQuery(`^select count\(\*\) from soft_post$`).WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(3))
+		_, err := db.Count("soft_post", "*").Exec()
+		check(t, err)
+		check(t, db.Register(softPost{}))
+	}
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestSoftDeleteCountOnlyDeletedWithoutModel(t *testing.T) {
+	db, _, err := getMockDB()
+	check(t, err)
+	if _, err := db.Count("soft_post", "*").OnlyDeleted().Exec(); err == nil {
+		t.Fatalf("expected err")
+	}
+}
+
+func TestSoftDeleteSelectFrom(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	mock.ExpectQuery(`^select body from soft_post where soft_post\.deleted_at is null$`).WillReturnRows(sqlmock.NewRows([]string{"body"}).AddRow("foo"))
+	mock.ExpectQuery(`^select \* from soft_post where soft_post\.deleted_at is null$`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	var bodies []string
+	check(t, db.Select("body").From("soft_post").Model(&softPost{}).Get(&bodies))
+	var posts []softPost
+	check(t, db.Select("*").From("soft_post").Get(&posts))
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestSoftDeleteSelectFromOnlyDeletedWithoutModel(t *testing.T) {
+	db, _, err := getMockDB()
+	check(t, err)
+	var bodies []string
+	if err := db.Select("body").From("soft_post").OnlyDeleted().Get(&bodies); err == nil {
+		t.Fatalf("expected err")
+	}
+}
+
+func TestSoftDeleteSQLite(t *testing.T) {
+	db := getSQLiteDB(t, "create table soft_post (id integer not null primary key, body text, deleted_at datetime)")
+	post := softPost{Body: "foo"}
+	_, err := db.Insert(&post)
+	check(t, err)
+	_, err = db.Delete(&post)
+	check(t, err)
+	var posts []softPost
+	check(t, db.Select("*").Get(&posts))
+	equals(t, len(posts), 0)
+	check(t, db.Select("*").WithDeleted().Get(&posts))
+	equals(t, len(posts), 1)
+	_, err = db.Restore(&post)
+	check(t, err)
+	check(t, db.Select("*").Get(&posts))
+	equals(t, len(posts), 1)
+}
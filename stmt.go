@@ -0,0 +1,168 @@
+package gosql
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"sync"
+)
+
+// deleteKey, softDeleteKey and restoreKey are the keys in model.queries
+// of the statements deleting, soft deleting and restoring a row.
+type deleteKey struct{}
+type softDeleteKey struct{}
+type restoreKey struct{}
+
+// insertKey is the key in model.queries of the statement inserting a
+// row. Its bits are set for the primary fields that are zero and left
+// out of the statement.
+type insertKey uint64
+
+// updateKey is the key in model.queries of the statement updating the
+// fields at the indecies it encodes.
+type updateKey string
+
+func newUpdateKey(fieldIndecies []int) updateKey {
+	b := make([]byte, 0, 2*len(fieldIndecies))
+	for _, i := range fieldIndecies {
+		b = append(b, byte(i>>8), byte(i))
+	}
+	return updateKey(b)
+}
+
+// getCachedQuery returns the statement cached for key, which is built
+// and cached if there is none.
+func (m *model) getCachedQuery(key interface{}, build func() string) string {
+	if query, ok := m.queries.Load(key); ok {
+		return query.(string)
+	}
+	query, _ := m.queries.LoadOrStore(key, build())
+	return query.(string)
+}
+
+// cachedInsert is a cached insert statement and the indecies of the
+// fields it inserts.
+type cachedInsert struct {
+	fieldIndecies []int
+	query         string
+}
+
+// getCachedInsert returns the insert statement for v, which depends on
+// which of its primary fields are zero.
+func (m *model) getCachedInsert(v reflect.Value) *cachedInsert {
+	var key insertKey
+	for n, i := range m.primaryFieldIndecies {
+		if m.fieldValue(v, i).IsZero() {
+			key |= 1 << n
+		}
+	}
+	if insert, ok := m.queries.Load(key); ok {
+		return insert.(*cachedInsert)
+	}
+	fieldIndecies := m.getInsertFieldIndecies(v)
+	insert, _ := m.queries.LoadOrStore(key, &cachedInsert{
+		fieldIndecies: fieldIndecies,
+		query:         m.getInsertManyQuery(fieldIndecies, 1),
+	})
+	return insert.(*cachedInsert)
+}
+
+// stmtExecutor runs queries as prepared statements, which are prepared
+// the first time they are run. In a transaction, the statements are
+// prepared on and kept by the transaction.
+type stmtExecutor struct {
+	db *DB
+	tx *Tx
+}
+
+// executor returns the executor for the statements generated for
+// models.
+func (db *DB) executor() executor {
+	if db.prepared {
+		return stmtExecutor{db: db}
+	}
+	return db.db
+}
+
+// executor returns the executor for the statements generated for
+// models.
+func (t *Tx) executor() executor {
+	if t.db.prepared {
+		return stmtExecutor{db: t.db, tx: t}
+	}
+	return t.tx
+}
+
+// preparer prepares statements. It is implemented by sql.DB and
+// sql.Tx.
+type preparer interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// prepare returns the statement for query kept in stmts, which is
+// prepared by p if it is run for the first time.
+func prepare(ctx context.Context, p preparer, stmts *sync.Map, query string) (*sql.Stmt, error) {
+	if stmt, ok := stmts.Load(query); ok {
+		return stmt.(*sql.Stmt), nil
+	}
+	stmt, err := p.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if cached, loaded := stmts.LoadOrStore(query, stmt); loaded {
+		stmt.Close()
+		return cached.(*sql.Stmt), nil
+	}
+	return stmt, nil
+}
+
+// updateExecutor returns the executor for the update of the fields at
+// the given indecies. Only the update of all fields is prepared, since a
+// model has an update statement for every subset of its fields, and
+// keeping them all prepared could exceed the number of prepared
+// statements the database allows.
+func (m *model) updateExecutor(e ExecerContext, fieldIndecies []int) ExecerContext {
+	s, ok := e.(stmtExecutor)
+	if !ok || newUpdateKey(fieldIndecies) == m.updateAllKey {
+		return e
+	}
+	if s.tx != nil {
+		return s.tx.tx
+	}
+	return s.db.db
+}
+
+func (e stmtExecutor) stmt(ctx context.Context, query string) (*sql.Stmt, error) {
+	if e.tx != nil {
+		// statements prepared on the transaction are closed with it
+		return prepare(ctx, e.tx.tx, &e.tx.stmts, query)
+	}
+	return prepare(ctx, e.db.db, &e.db.stmts, query)
+}
+
+func (e stmtExecutor) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	stmt, err := e.stmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.ExecContext(ctx, args...)
+}
+
+func (e stmtExecutor) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	stmt, err := e.stmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.QueryContext(ctx, args...)
+}
+
+func (e stmtExecutor) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	stmt, err := e.stmt(ctx, query)
+	if err != nil {
+		if e.tx != nil {
+			return errRow(ctx, e.tx.tx, err)
+		}
+		return errRow(ctx, e.db.db, err)
+	}
+	return stmt.QueryRowContext(ctx, args...)
+}
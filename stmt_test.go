@@ -0,0 +1,157 @@
+package gosql_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/twharmon/gosql"
+)
+
+func TestInsertCachedByZeroPrimary(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	mock.ExpectExec(`^insert into t \(name\) values \(\?\)$`).WithArgs("foo").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`^insert into t \(id, name\) values \(\?, \?\)$`).WithArgs(5, "bar").WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectExec(`^insert into t \(name\) values \(\?\)$`).WithArgs("baz").WillReturnResult(sqlmock.NewResult(2, 1))
+	_, err = db.Insert(&T{Name: "foo"})
+	check(t, err)
+	_, err = db.Insert(&T{ID: 5, Name: "bar"})
+	check(t, err)
+	_, err = db.Insert(&T{Name: "baz"})
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateCachedByColumns(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID    int `idx:"primary"`
+		Name  string
+		Email string
+	}
+	test := T{ID: 1, Name: "foo", Email: "bar"}
+	mock.ExpectExec(`^update t set name = \? where id = \?$`).WithArgs("foo", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`^update t set email = \? where id = \?$`).WithArgs("bar", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`^update t set name = \? where id = \?$`).WithArgs("foo", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.UpdateColumns(&test, "name")
+	check(t, err)
+	_, err = db.UpdateColumns(&test, "email")
+	check(t, err)
+	_, err = db.UpdateColumns(&test, "name")
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestPreparedStatements(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithPreparedStatements())
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	prep := mock.ExpectPrepare(`^insert into t \(id, name\) values \(\?, \?\)$`)
+	prep.ExpectExec().WithArgs(1, "foo").WillReturnResult(sqlmock.NewResult(1, 1))
+	prep.ExpectExec().WithArgs(2, "bar").WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectPrepare(`^delete from t where id = \?$`).ExpectExec().WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.Insert(&T{ID: 1, Name: "foo"})
+	check(t, err)
+	_, err = db.Insert(&T{ID: 2, Name: "bar"})
+	check(t, err)
+	_, err = db.Delete(&T{ID: 2})
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestPreparedStatementsClose(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithPreparedStatements())
+	check(t, err)
+	type T struct {
+		ID int `idx:"primary"`
+	}
+	mock.ExpectPrepare(`^delete from t where id = \?$`).WillBeClosed().ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.Delete(&T{ID: 1})
+	check(t, err)
+	check(t, db.Close())
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestPreparedStatementsReturningPrepareError(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithDialect(gosql.PostgreSQL), gosql.WithPreparedStatements())
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	prepareErr := errors.New("too many prepared statements")
+	mock.ExpectPrepare(`^insert into t \(name\) values \(\$1\) returning id$`).WillReturnError(prepareErr)
+	_, err = db.Insert(&T{Name: "foo"})
+	equals(t, prepareErr, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestPreparedStatementsTx(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithPreparedStatements())
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`^update t set name = \? where id = \?$`).ExpectExec().WithArgs("foo", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	tx, err := db.Begin()
+	check(t, err)
+	_, err = tx.Update(&T{ID: 1, Name: "foo"})
+	check(t, err)
+	check(t, tx.Commit())
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestPreparedStatementsPartialUpdate(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithPreparedStatements())
+	check(t, err)
+	type T struct {
+		ID    int `idx:"primary"`
+		Name  string
+		Email string
+	}
+	test := T{ID: 1, Name: "foo", Email: "bar"}
+	mock.ExpectExec(`^update t set name = \? where id = \?$`).WithArgs("foo", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare(`^update t set name = \?, email = \? where id = \?$`).ExpectExec().WithArgs("foo", "bar", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.UpdateColumns(&test, "name")
+	check(t, err)
+	_, err = db.UpdateColumns(&test, "name", "email")
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestPreparedStatementsSQLite(t *testing.T) {
+	db := getSQLiteDB(t, "create table t (id integer not null primary key, name text not null)", gosql.WithPreparedStatements())
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	for i := 0; i < 3; i++ {
+		test := T{Name: "foo"}
+		_, err := db.Insert(&test)
+		check(t, err)
+		equals(t, i+1, test.ID)
+	}
+	tx, err := db.Begin()
+	check(t, err)
+	_, err = tx.Update(&T{ID: 2, Name: "bar"})
+	check(t, err)
+	_, err = tx.Delete(&T{ID: 3})
+	check(t, err)
+	check(t, tx.Commit())
+	var test []T
+	check(t, db.Select("*").OrderBy("id").Get(&test))
+	equals(t, 2, len(test))
+	equals(t, "bar", test[1].Name)
+}
@@ -1,7 +1,10 @@
 package gosql_test
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"io"
 	"os"
 	"reflect"
 	"strings"
@@ -37,18 +40,109 @@ func check(f fataler, err error) {
 	}
 }
 
-func getMockDB() (*gosql.DB, sqlmock.Sqlmock, error) {
+func getMockDB(options ...gosql.Option) (*gosql.DB, sqlmock.Sqlmock, error) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		panic(err)
 	}
-	return gosql.New(db), mock, err
+	return gosql.New(db, options...), mock, err
 }
 
-func getSQLiteDB(f fataler, q string) *gosql.DB {
+func getSQLiteDB(f fataler, q string, options ...gosql.Option) *gosql.DB {
 	os.Remove("/tmp/foo.db")
 	sqliteDB, err := sql.Open("sqlite3", "/tmp/foo.db")
 	check(f, err)
 	sqliteDB.Exec(q)
-	return gosql.New(sqliteDB)
+	return gosql.New(sqliteDB, append([]gosql.Option{gosql.WithDialect(gosql.SQLite)}, options...)...)
+}
+
+// rowsDriver is a driver whose queries return the same rows without
+// touching a database, so benchmarks measure the cost of gosql alone.
+type rowsDriver struct {
+	columns []string
+	rows    [][]driver.Value
+
+	// txOptions are the options of the last transaction begun.
+	txOptions driver.TxOptions
+}
+
+func (d *rowsDriver) Open(string) (driver.Conn, error) {
+	return d, nil
+}
+
+func (d *rowsDriver) Prepare(string) (driver.Stmt, error) {
+	return d, nil
+}
+
+func (d *rowsDriver) Close() error {
+	return nil
+}
+
+func (d *rowsDriver) Begin() (driver.Tx, error) {
+	return nil, driver.ErrSkip
+}
+
+func (d *rowsDriver) BeginTx(_ context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	d.txOptions = opts
+	return d, nil
+}
+
+func (d *rowsDriver) Commit() error {
+	return nil
+}
+
+func (d *rowsDriver) Rollback() error {
+	return nil
+}
+
+func (d *rowsDriver) NumInput() int {
+	return -1
+}
+
+func (d *rowsDriver) Exec([]driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+
+func (d *rowsDriver) Query([]driver.Value) (driver.Rows, error) {
+	return &driverRows{d: d}, nil
+}
+
+type driverRows struct {
+	d *rowsDriver
+	i int
+}
+
+func (r *driverRows) Columns() []string {
+	return r.d.columns
+}
+
+func (r *driverRows) Close() error {
+	return nil
+}
+
+func (r *driverRows) Next(dest []driver.Value) error {
+	if r.i == len(r.d.rows) {
+		return io.EOF
+	}
+	copy(dest, r.d.rows[r.i])
+	r.i++
+	return nil
+}
+
+// getRowsDB returns a DB whose queries return rows of the given
+// columns.
+func getRowsDB(columns []string, rows [][]driver.Value) *gosql.DB {
+	return gosql.New(sql.OpenDB(driverConnector{&rowsDriver{columns: columns, rows: rows}}))
+}
+
+type driverConnector struct {
+	d *rowsDriver
+}
+
+func (c driverConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.d, nil
+}
+
+func (c driverConnector) Driver() driver.Driver {
+	return c.d
 }
@@ -1,14 +1,20 @@
 package gosql
 
 import (
+	"context"
 	"database/sql"
 	"reflect"
+	"sync"
 )
 
 // Tx .
 type Tx struct {
 	tx *sql.Tx
 	db *DB
+
+	// stmts holds the statements prepared on the transaction by their
+	// query if the DB runs prepared statements.
+	stmts sync.Map
 }
 
 // Commit .
@@ -21,53 +27,202 @@ func (t *Tx) Rollback() error {
 	return t.tx.Rollback()
 }
 
-// Insert insterts a row in the database.
+// Insert insterts a row in the database. If the primary field is
+// generated by the database, it is set on obj.
 func (t *Tx) Insert(obj interface{}) (sql.Result, error) {
+	return t.InsertContext(context.Background(), obj)
+}
+
+// InsertContext insterts a row in the database using the given
+// context.
+func (t *Tx) InsertContext(ctx context.Context, obj interface{}) (sql.Result, error) {
+	m, err := t.db.getModelOf(reflect.TypeOf(obj))
+	if err != nil {
+		return nil, err
+	}
+	v := reflect.ValueOf(obj).Elem()
+	return withHooks([]reflect.Value{v}, t, beforeInsert, afterInsert, func() (sql.Result, error) {
+		return m.insert(ctx, t.executor(), v, t.db.clock())
+	})
+}
+
+// InsertMany inserts a row in the database for each element of slice,
+// which must be a slice of models or of pointers to models. The rows
+// are inserted with as few queries as the dialect allows. If the
+// dialect supports returning columns from an insert, primary fields
+// generated by the database are set on the elements.
+func (t *Tx) InsertMany(slice interface{}) (sql.Result, error) {
+	return t.InsertManyContext(context.Background(), slice)
+}
+
+// InsertManyContext is like InsertMany, but uses the given context.
+func (t *Tx) InsertManyContext(ctx context.Context, slice interface{}) (sql.Result, error) {
+	m, values, err := t.db.getModelOfSlice(slice)
+	if err != nil {
+		return nil, err
+	}
+	return withHooks(values, t, beforeInsert, afterInsert, func() (sql.Result, error) {
+		return m.insertMany(ctx, t.tx, values, t.db.clock())
+	})
+}
+
+// Upsert inserts a row in the database, or updates the row it conflicts
+// with as configured by opts. All columns other than the primary
+// columns are updated if opts is nil. The insert hooks of the model are
+// run whether it is inserted or updated.
+func (t *Tx) Upsert(obj interface{}, opts *UpsertOptions) (sql.Result, error) {
+	return t.UpsertContext(context.Background(), obj, opts)
+}
+
+// UpsertContext is like Upsert, but uses the given context.
+func (t *Tx) UpsertContext(ctx context.Context, obj interface{}, opts *UpsertOptions) (sql.Result, error) {
 	m, err := t.db.getModelOf(reflect.TypeOf(obj))
 	if err != nil {
 		return nil, err
 	}
 	v := reflect.ValueOf(obj).Elem()
-	return t.tx.Exec(m.getInsertQuery(v), m.getArgs(v)...)
+	return withHooks([]reflect.Value{v}, t, beforeInsert, afterInsert, func() (sql.Result, error) {
+		return m.upsert(ctx, t.tx, v, opts, t.db.clock())
+	})
 }
 
-// Update updates a row in the database.
+// Update updates a row in the database. If the model embeds a
+// Snapshot, only the columns that changed since it was loaded are
+// updated.
 func (t *Tx) Update(obj interface{}) (sql.Result, error) {
+	return t.UpdateContext(context.Background(), obj)
+}
+
+// UpdateContext updates a row in the database using the given context.
+func (t *Tx) UpdateContext(ctx context.Context, obj interface{}) (sql.Result, error) {
+	m, err := t.db.getModelOf(reflect.TypeOf(obj))
+	if err != nil {
+		return nil, err
+	}
+	v := reflect.ValueOf(obj).Elem()
+	return withHooks([]reflect.Value{v}, t, beforeUpdate, afterUpdate, func() (sql.Result, error) {
+		return m.update(ctx, t.executor(), v, m.getUpdateFieldIndecies(v), t.db.clock())
+	})
+}
+
+// UpdateColumns updates the given columns of a row in the database.
+func (t *Tx) UpdateColumns(obj interface{}, columns ...string) (sql.Result, error) {
+	return t.UpdateColumnsContext(context.Background(), obj, columns...)
+}
+
+// UpdateColumnsContext is like UpdateColumns, but uses the given
+// context.
+func (t *Tx) UpdateColumnsContext(ctx context.Context, obj interface{}, columns ...string) (sql.Result, error) {
 	m, err := t.db.getModelOf(reflect.TypeOf(obj))
 	if err != nil {
 		return nil, err
 	}
+	fieldIndecies, err := m.getFieldIndeciesByColumns(columns)
+	if err != nil {
+		return nil, err
+	}
 	v := reflect.ValueOf(obj).Elem()
-	return t.tx.Exec(m.getUpdateQuery(), m.getArgsPrimaryLast(v)...)
+	return withHooks([]reflect.Value{v}, t, beforeUpdate, afterUpdate, func() (sql.Result, error) {
+		return m.update(ctx, t.executor(), v, fieldIndecies, t.db.clock())
+	})
 }
 
-// Delete deletes a row from the database.
+// Delete deletes a row from the database. If the model has a field
+// tagged `soft:"delete"`, the row is soft deleted by setting the field
+// to the current time instead.
 func (t *Tx) Delete(obj interface{}) (sql.Result, error) {
+	return t.DeleteContext(context.Background(), obj)
+}
+
+// DeleteContext deletes a row from the database using the given
+// context.
+func (t *Tx) DeleteContext(ctx context.Context, obj interface{}) (sql.Result, error) {
 	m, err := t.db.getModelOf(reflect.TypeOf(obj))
 	if err != nil {
 		return nil, err
 	}
 	v := reflect.ValueOf(obj).Elem()
-	var inserts []interface{}
-	for _, i := range m.primaryFieldIndecies {
-		inserts = append(inserts, v.Field(i).Interface())
+	return withHooks([]reflect.Value{v}, t, beforeDelete, afterDelete, func() (sql.Result, error) {
+		if m.softDeleteFieldIndex >= 0 {
+			return m.softDelete(ctx, t.executor(), v, t.db.clock())
+		}
+		return t.executor().ExecContext(ctx, m.getDeleteQuery(), m.getPrimaryArgs(v)...)
+	})
+}
+
+// HardDelete deletes a row from the database, even if the model has a
+// field tagged `soft:"delete"`.
+func (t *Tx) HardDelete(obj interface{}) (sql.Result, error) {
+	return t.HardDeleteContext(context.Background(), obj)
+}
+
+// HardDeleteContext is like HardDelete, but uses the given context.
+func (t *Tx) HardDeleteContext(ctx context.Context, obj interface{}) (sql.Result, error) {
+	m, err := t.db.getModelOf(reflect.TypeOf(obj))
+	if err != nil {
+		return nil, err
+	}
+	v := reflect.ValueOf(obj).Elem()
+	return withHooks([]reflect.Value{v}, t, beforeDelete, afterDelete, func() (sql.Result, error) {
+		return t.executor().ExecContext(ctx, m.getDeleteQuery(), m.getPrimaryArgs(v)...)
+	})
+}
+
+// Restore restores a soft deleted row by setting the model's field
+// tagged `soft:"delete"` to null.
+func (t *Tx) Restore(obj interface{}) (sql.Result, error) {
+	return t.RestoreContext(context.Background(), obj)
+}
+
+// RestoreContext is like Restore, but uses the given context.
+func (t *Tx) RestoreContext(ctx context.Context, obj interface{}) (sql.Result, error) {
+	m, err := t.db.getModelOf(reflect.TypeOf(obj))
+	if err != nil {
+		return nil, err
 	}
-	return t.tx.Exec(m.getDeleteQuery(), inserts...)
+	return m.restore(ctx, t.executor(), reflect.ValueOf(obj).Elem())
 }
 
-// Exec is a wrapper around sql.DB.Exec().
+// Exec is a wrapper around sql.Tx.Exec().
 func (t *Tx) Exec(query string, args ...interface{}) (sql.Result, error) {
-	return t.tx.Exec(query, args...)
+	return t.ExecContext(context.Background(), query, args...)
+}
+
+// ExecContext is a wrapper around sql.Tx.ExecContext().
+func (t *Tx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	query, args, err := t.db.expandQuery(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return t.tx.ExecContext(ctx, query, args...)
 }
 
-// Query is a wrapper around sql.DB.Query().
+// Query is a wrapper around sql.Tx.Query().
 func (t *Tx) Query(query string, args ...interface{}) (*sql.Rows, error) {
-	return t.tx.Query(query, args...)
+	return t.QueryContext(context.Background(), query, args...)
 }
 
-// QueryRow is a wrapper around sql.DB.QueryRow().
+// QueryContext is a wrapper around sql.Tx.QueryContext().
+func (t *Tx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	query, args, err := t.db.expandQuery(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return t.tx.QueryContext(ctx, query, args...)
+}
+
+// QueryRow is a wrapper around sql.Tx.QueryRow().
 func (t *Tx) QueryRow(query string, args ...interface{}) *sql.Row {
-	return t.tx.QueryRow(query, args...)
+	return t.QueryRowContext(context.Background(), query, args...)
+}
+
+// QueryRowContext is a wrapper around sql.Tx.QueryRowContext().
+func (t *Tx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	query, args, err := t.db.expandQuery(query, args)
+	if err != nil {
+		return errRow(ctx, t.tx, err)
+	}
+	return t.tx.QueryRowContext(ctx, query, args...)
 }
 
 // Select selects columns of a table.
@@ -75,6 +230,7 @@ func (t *Tx) Select(fields ...string) *SelectQuery {
 	sq := new(SelectQuery)
 	sq.db = t.db
 	sq.querier = t.tx
+	sq.tx = t
 	sq.fields = fields
 	return sq
 }
@@ -93,11 +249,32 @@ func (t *Tx) Count(table string, count string) *CountQuery {
 	cq := new(CountQuery)
 	cq.db = t.db
 	cq.queryRower = t.tx
+	cq.fn = "count"
 	cq.table = table
-	cq.count = count
+	cq.column = count
 	return cq
 }
 
+// Sum starts a query for the sum of expr over the rows in a table.
+func (t *Tx) Sum(table string, expr string) *AggregateQuery {
+	return t.db.newAggregateQuery(t.tx, "sum", table, expr)
+}
+
+// Avg starts a query for the average of expr over the rows in a table.
+func (t *Tx) Avg(table string, expr string) *AggregateQuery {
+	return t.db.newAggregateQuery(t.tx, "avg", table, expr)
+}
+
+// Min starts a query for the minimum of expr over the rows in a table.
+func (t *Tx) Min(table string, expr string) *AggregateQuery {
+	return t.db.newAggregateQuery(t.tx, "min", table, expr)
+}
+
+// Max starts a query for the maximum of expr over the rows in a table.
+func (t *Tx) Max(table string, expr string) *AggregateQuery {
+	return t.db.newAggregateQuery(t.tx, "max", table, expr)
+}
+
 // ManualDelete starts a query for manually deleting rows in a table.
 func (t *Tx) ManualDelete(table string) *DeleteQuery {
 	dq := new(DeleteQuery)
@@ -1,6 +1,7 @@
 package gosql
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
@@ -9,13 +10,15 @@ import (
 // UpdateQuery holds information for an update query.
 type UpdateQuery struct {
 	db        *DB
-	execer    Execer
+	execer    ExecerContext
 	table     string
 	joins     []string
+	joinArgs  []interface{}
 	wheres    []*where
 	sets      []string
 	whereArgs []interface{}
 	setArgs   []interface{}
+	err       error
 }
 
 // Execer .
@@ -23,56 +26,100 @@ type Execer interface {
 	Exec(query string, args ...interface{}) (sql.Result, error)
 }
 
-// Where specifies which rows will be returned.
-func (uq *UpdateQuery) Where(condition string, args ...interface{}) *UpdateQuery {
+// ExecerContext .
+type ExecerContext interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// setErr records the first error found while building the query, which
+// is returned when the query is run.
+func (uq *UpdateQuery) setErr(err error) {
+	if uq.err == nil {
+		uq.err = err
+	}
+}
+
+// Where specifies which rows will be returned. The condition is a
+// string or an expr.Expr.
+func (uq *UpdateQuery) Where(condition interface{}, args ...interface{}) *UpdateQuery {
+	c, args, err := toCondition(condition, args)
+	uq.setErr(err)
 	w := &where{
 		conjunction: " and ",
-		condition:   condition,
+		condition:   c,
 	}
 	uq.wheres = append(uq.wheres, w)
 	uq.whereArgs = append(uq.whereArgs, args...)
 	return uq
 }
 
-// OrWhere specifies which rows will be returned.
-func (uq *UpdateQuery) OrWhere(condition string, args ...interface{}) *UpdateQuery {
+// OrWhere specifies which rows will be returned. The condition is a
+// string or an expr.Expr.
+func (uq *UpdateQuery) OrWhere(condition interface{}, args ...interface{}) *UpdateQuery {
+	c, args, err := toCondition(condition, args)
+	uq.setErr(err)
 	w := &where{
 		conjunction: " or ",
-		condition:   condition,
+		condition:   c,
 	}
 	uq.wheres = append(uq.wheres, w)
 	uq.whereArgs = append(uq.whereArgs, args...)
 	return uq
 }
 
-// Set specifies how to update a row in a table.
+// Set specifies how to update a row in a table. Named parameters in set
+// are bound from args.
 func (uq *UpdateQuery) Set(set string, args ...interface{}) *UpdateQuery {
+	set, args, err := bindNamed(set, args)
+	uq.setErr(err)
 	uq.sets = append(uq.sets, set)
 	uq.setArgs = append(uq.setArgs, args...)
 	return uq
 }
 
-// Join joins another table to this query.
-func (uq *UpdateQuery) Join(join string) *UpdateQuery {
+// Join joins another table to this query. Named parameters in join are
+// bound from args.
+func (uq *UpdateQuery) Join(join string, args ...interface{}) *UpdateQuery {
+	join, args, err := bindNamed(join, args)
+	uq.setErr(err)
 	uq.joins = append(uq.joins, fmt.Sprintf(" join %s", join))
+	uq.joinArgs = append(uq.joinArgs, args...)
 	return uq
 }
 
-// LeftJoin joins another table to this query.
-func (uq *UpdateQuery) LeftJoin(join string) *UpdateQuery {
+// LeftJoin joins another table to this query. Named parameters in join
+// are bound from args.
+func (uq *UpdateQuery) LeftJoin(join string, args ...interface{}) *UpdateQuery {
+	join, args, err := bindNamed(join, args)
+	uq.setErr(err)
 	uq.joins = append(uq.joins, fmt.Sprintf(" left join %s", join))
+	uq.joinArgs = append(uq.joinArgs, args...)
 	return uq
 }
 
 // Exec executes the query.
 func (uq *UpdateQuery) Exec() (sql.Result, error) {
-	args := uq.setArgs
+	return uq.ExecContext(context.Background())
+}
+
+// ExecContext executes the query using the given context.
+func (uq *UpdateQuery) ExecContext(ctx context.Context) (sql.Result, error) {
+	if uq.err != nil {
+		return nil, uq.err
+	}
+	args := append(uq.joinArgs[:len(uq.joinArgs):len(uq.joinArgs)], uq.setArgs...)
 	args = append(args, uq.whereArgs...)
-	return uq.execer.Exec(uq.String(), args...)
+	query, args := bind(uq.db.dialect, uq.sql(), args)
+	return uq.execer.ExecContext(ctx, query, args...)
 }
 
 // String returns the string representation of UpdateQuery.
 func (uq *UpdateQuery) String() string {
+	return rebind(uq.db.dialect, uq.sql())
+}
+
+// sql returns the query with ? placeholders.
+func (uq *UpdateQuery) sql() string {
 	var q strings.Builder
 	q.WriteString("update ")
 	q.WriteString(uq.table)
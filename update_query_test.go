@@ -1,6 +1,7 @@
 package gosql_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -50,3 +51,13 @@ func TestManualUpdateJoin(t *testing.T) {
 	check(t, err)
 	check(t, mock.ExpectationsWereMet())
 }
+
+func TestManualUpdateExecContext(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	val := 1
+	mock.ExpectExec(`^update t set val = \?$`).WithArgs(val).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.ManualUpdate("t").Set("val = ?", val).ExecContext(context.Background())
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,100 @@
+package gosql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// UpsertOptions configure what Upsert does when the row being inserted
+// conflicts with an existing row.
+type UpsertOptions struct {
+	// Columns are the columns of the existing row that are updated. All
+	// columns other than the primary columns and the columns tagged
+	// `auto:"create_time"` and `lock:"version"` are updated if Columns is
+	// empty. The version column of the existing row is incremented
+	// whenever it is updated, and can not be in Columns.
+	Columns []string
+
+	// DoNothing leaves the existing row as it is.
+	DoNothing bool
+
+	// Conflict are the columns of the unique index that the rows
+	// conflict on. The primary columns are used if Conflict is empty.
+	// MySQL ignores Conflict, since it updates on any duplicate key.
+	Conflict []string
+}
+
+// upsert inserts v, or updates the row it conflicts with as configured
+// by opts. The automatic time fields are set to now. The snapshot of v
+// is taken if every column was written, or refreshed for the columns
+// that were updated otherwise.
+func (m *model) upsert(ctx context.Context, e ExecerContext, v reflect.Value, opts *UpsertOptions, now time.Time) (sql.Result, error) {
+	m.setInsertTimes(v, now)
+	query, err := m.getUpsertQuery(v, opts)
+	if err != nil {
+		return nil, err
+	}
+	res, err := e.ExecContext(ctx, query, m.getArgs(v)...)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case opts == nil || !opts.DoNothing && len(opts.Columns) == 0:
+		m.takeSnapshot(v)
+	case !opts.DoNothing:
+		fieldIndecies := make([]int, len(opts.Columns))
+		for j, column := range opts.Columns {
+			fieldIndecies[j] = m.getFieldIndexByColumn(column)
+		}
+		m.refreshSnapshot(v, fieldIndecies)
+	}
+	return res, nil
+}
+
+func (m *model) getUpsertQuery(v reflect.Value, opts *UpsertOptions) (string, error) {
+	if opts == nil {
+		opts = new(UpsertOptions)
+	}
+	conflict := opts.Conflict
+	if len(conflict) == 0 {
+		for _, i := range m.primaryFieldIndecies {
+			conflict = append(conflict, m.fields[i].column)
+		}
+	}
+	var update []string
+	if !opts.DoNothing {
+		update = opts.Columns
+		if len(update) == 0 {
+			for i, f := range m.fields {
+				if !isIntIn(i, m.primaryFieldIndecies) && i != m.createTimeFieldIndex && i != m.versionFieldIndex {
+					update = append(update, f.column)
+				}
+			}
+		}
+	}
+	for _, columns := range [][]string{conflict, update} {
+		for _, column := range columns {
+			i := m.getFieldIndexByColumn(column)
+			if i < 0 {
+				return "", fmt.Errorf("model %s has no field for column %s", m.name, column)
+			}
+			if i == m.versionFieldIndex {
+				return "", fmt.Errorf("version column %s of model %s can not be upserted", column, m.name)
+			}
+		}
+	}
+	clause := m.dialect.Upsert(conflict, update)
+	if clause == "" {
+		return "", fmt.Errorf("dialect does not support upserts")
+	}
+	if m.versionFieldIndex >= 0 && len(update) > 0 {
+		// the version of the existing row is incremented instead of
+		// being overwritten, so models loaded before are stale
+		version := m.dialect.Quote(m.fields[m.versionFieldIndex].column)
+		clause += ", " + version + " = " + m.dialect.Quote(m.table) + "." + version + " + 1"
+	}
+	return m.getInsertQuery(v) + clause, nil
+}
@@ -0,0 +1,187 @@
+package gosql_test
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/twharmon/gosql"
+)
+
+func TestUpsertMySQL(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID    int `idx:"primary"`
+		Name  string
+		Email string
+	}
+	model := T{5, "foo", "foo@example.com"}
+	mock.ExpectExec(`^insert into t \(id, name, email\) values \(\?, \?, \?\) on duplicate key update name = values\(name\), email = values\(email\)$`).WithArgs(model.ID, model.Name, model.Email).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.Upsert(&model, nil)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertMySQLColumns(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID    int `idx:"primary"`
+		Name  string
+		Email string
+	}
+	model := T{5, "foo", "foo@example.com"}
+	mock.ExpectExec(`^insert into t \(id, name, email\) values \(\?, \?, \?\) on duplicate key update email = values\(email\)$`).WithArgs(model.ID, model.Name, model.Email).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.Upsert(&model, &gosql.UpsertOptions{Columns: []string{"email"}})
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertMySQLDoNothing(t *testing.T) {
+	db, mock, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	model := T{5, "foo"}
+	mock.ExpectExec(`^insert into t \(id, name\) values \(\?, \?\) on duplicate key update id = id$`).WithArgs(model.ID, model.Name).WillReturnResult(sqlmock.NewResult(0, 0))
+	_, err = db.Upsert(&model, &gosql.UpsertOptions{DoNothing: true})
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertPostgreSQL(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithDialect(gosql.PostgreSQL))
+	check(t, err)
+	type T struct {
+		ID    int `idx:"primary"`
+		Name  string
+		Email string
+	}
+	model := T{5, "foo", "foo@example.com"}
+	mock.ExpectExec(`^insert into t \(id, name, email\) values \(\$1, \$2, \$3\) on conflict \(id\) do update set name = excluded\.name, email = excluded\.email$`).WithArgs(model.ID, model.Name, model.Email).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.Upsert(&model, nil)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertPostgreSQLConflictDoNothing(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithDialect(gosql.PostgreSQL))
+	check(t, err)
+	type T struct {
+		ID    int `idx:"primary"`
+		Email string
+	}
+	model := T{Email: "foo@example.com"}
+	mock.ExpectBegin()
+	mock.ExpectExec(`^insert into t \(email\) values \(\$1\) on conflict \(email\) do nothing$`).WithArgs(model.Email).WillReturnResult(sqlmock.NewResult(0, 0))
+	tx, err := db.Begin()
+	check(t, err)
+	_, err = tx.Upsert(&model, &gosql.UpsertOptions{DoNothing: true, Conflict: []string{"email"}})
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertUnknownColumn(t *testing.T) {
+	db, _, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	if _, err := db.Upsert(&T{5, "foo"}, &gosql.UpsertOptions{Columns: []string{"nope"}}); err == nil {
+		t.Fatalf("expected err")
+	} else {
+		contains(t, err.Error(), "nope")
+	}
+}
+
+func TestUpsertPostgreSQLVersion(t *testing.T) {
+	db, mock, err := getMockDB(gosql.WithDialect(gosql.PostgreSQL))
+	check(t, err)
+	type T struct {
+		ID      int `idx:"primary"`
+		Name    string
+		Version int `lock:"version"`
+	}
+	model := T{5, "foo", 2}
+	mock.ExpectExec(`^insert into t \(id, name, version\) values \(\$1, \$2, \$3\) on conflict \(id\) do update set name = excluded\.name, version = t\.version \+ 1$`).WithArgs(5, "foo", 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = db.Upsert(&model, nil)
+	check(t, err)
+	check(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertSQLiteVersion(t *testing.T) {
+	db := getSQLiteDB(t, "create table t (id integer not null primary key, name text, version integer not null)")
+	type T struct {
+		ID      int `idx:"primary"`
+		Name    string
+		Version int `lock:"version"`
+	}
+	_, err := db.Upsert(&T{1, "foo", 0}, nil)
+	check(t, err)
+	_, err = db.Upsert(&T{1, "bar", 0}, nil)
+	check(t, err)
+	var model T
+	check(t, db.Select("*").Get(&model))
+	equals(t, T{1, "bar", 1}, model)
+}
+
+func TestUpsertVersionColumn(t *testing.T) {
+	db, _, err := getMockDB()
+	check(t, err)
+	type T struct {
+		ID      int `idx:"primary"`
+		Name    string
+		Version int `lock:"version"`
+	}
+	if _, err := db.Upsert(&T{5, "foo", 2}, &gosql.UpsertOptions{Columns: []string{"version"}}); err == nil {
+		t.Fatalf("expected err")
+	} else {
+		contains(t, err.Error(), "version")
+	}
+}
+
+// noUpsertDialect is a dialect that does not support upserts.
+type noUpsertDialect struct {
+	gosql.Dialect
+}
+
+func (noUpsertDialect) Upsert([]string, []string) string {
+	return ""
+}
+
+func TestUpsertUnsupported(t *testing.T) {
+	db, _, err := getMockDB(gosql.WithDialect(noUpsertDialect{gosql.MySQL}))
+	check(t, err)
+	type T struct {
+		ID   int `idx:"primary"`
+		Name string
+	}
+	if _, err := db.Upsert(&T{5, "foo"}, nil); err == nil {
+		t.Fatalf("expected err")
+	}
+}
+
+func ExampleDB_Upsert() {
+	os.Remove("/tmp/foo.db")
+	sqliteDB, _ := sql.Open("sqlite3", "/tmp/foo.db")
+	sqliteDB.Exec("create table user (id integer not null primary key, name text, email text)")
+	db := gosql.New(sqliteDB, gosql.WithDialect(gosql.SQLite))
+	type User struct {
+		ID    int `idx:"primary"`
+		Name  string
+		Email string
+	}
+	db.Insert(&User{ID: 1, Name: "Gopher", Email: "gopher@example.com"})
+	db.Upsert(&User{ID: 1, Name: "Gofer", Email: "gofer@example.com"}, &gosql.UpsertOptions{Columns: []string{"name"}})
+	db.Upsert(&User{ID: 1, Name: "Gofer", Email: "ignored@example.com"}, &gosql.UpsertOptions{DoNothing: true})
+	var user User
+	db.Select("*").Get(&user)
+	fmt.Println(user.Name, user.Email)
+	// Output: Gofer gopher@example.com
+}